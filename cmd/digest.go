@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
+)
+
+// runDigest implements "tools-marketplace digest <dir>". It writes the
+// SHA-256 digest of every content file into the pack's pack.json and returns
+// the process exit code: 0 on success, 1 if the pack is invalid, 2 on usage
+// errors.
+func runDigest(args []string) int {
+	fs := flag.NewFlagSet("digest", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tools-marketplace digest <pack-dir>\n\n")
+		fmt.Fprintf(fs.Output(), "Writes content digests into pack.json. Sign it afterwards with:\n  minisign -Sm <pack-dir>/pack.json\n")
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dir := "."
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		dir = fs.Arg(0)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	digests, err := packs.WriteDigests(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "digest: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Wrote %d digest(s) to pack.json. Sign it with: minisign -Sm %s\n", len(digests), filepath.Join(dir, "pack.json"))
+	return 0
+}
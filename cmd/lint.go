@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
+)
+
+// runLint implements "tools-marketplace lint [-json] [-strict] <dir>". It
+// prints one finding per line as file:line: severity: message [rule] and
+// returns the process exit code: 0 if clean, 1 if any errors (or, with
+// -strict, warnings) were found, 2 on usage errors.
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tools-marketplace lint [-json] [-strict] <pack-dir>\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	dir := "."
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		dir = fs.Arg(0)
+	default:
+		fs.Usage()
+		return 2
+	}
+
+	report, err := packs.LintPack(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "lint: %v\n", err)
+		return 2
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printLintReport(os.Stdout, report)
+	}
+
+	failed := report.Count(packs.SeverityError) > 0
+	if *strict && report.Count(packs.SeverityWarning) > 0 {
+		failed = true
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func printLintReport(w io.Writer, report *packs.LintReport) {
+	for _, issue := range report.Issues {
+		fmt.Fprintf(w, "%s: %s: %s [%s]\n", issue.Location(), issue.Severity, issue.Message, issue.Rule)
+	}
+	fmt.Fprintf(w, "%d error(s), %d warning(s), %d info\n",
+		report.Count(packs.SeverityError), report.Count(packs.SeverityWarning), report.Count(packs.SeverityInfo))
+}
@@ -1,5 +1,5 @@
 // Command tools-marketplace is the entry point for the tools.marketplace plugin
-// binary. It provides 15 MCP tools and 5 MCP prompts for managing installable
+// binary. It provides 40 MCP tools and 5 MCP prompts for managing installable
 // packs of skills, agents, and hooks from GitHub repositories.
 package main
 
@@ -10,6 +10,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 
 	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
@@ -19,7 +20,18 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "digest":
+			os.Exit(runDigest(os.Args[2:]))
+		}
+	}
+
 	workspace := flag.String("workspace", ".", "Root workspace directory")
+	storageMode := flag.String("storage", "orchestrator", "Storage backend: orchestrator or local")
+	storageDir := flag.String("storage-dir", "", "Root directory for local storage (default <workspace>/.projects)")
 
 	builder := plugin.New("tools.marketplace").
 		Version("0.1.0").
@@ -46,6 +58,18 @@ func main() {
 	mp.Workspace = *workspace
 	adapter.plugin = p
 
+	switch *storageMode {
+	case "orchestrator":
+	case "local":
+		dir := *storageDir
+		if dir == "" {
+			dir = filepath.Join(*workspace, ".projects")
+		}
+		store.SetBackend(storage.NewFileBackend(dir))
+	default:
+		log.Fatalf("tools.marketplace: unknown --storage %q (want orchestrator or local)", *storageMode)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -3,14 +3,15 @@ package toolsmarketplace
 import (
 	"context"
 	"log"
+	"path/filepath"
+	"time"
 
 	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
 	"github.com/orchestra-mcp/plugin-tools-marketplace/internal"
 	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
 	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
-	"github.com/orchestra-mcp/sdk-go/helpers"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/tools"
 	"github.com/orchestra-mcp/sdk-go/plugin"
-	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // Sender is the interface that the in-process router satisfies.
@@ -18,9 +19,28 @@ type Sender interface {
 	Send(ctx context.Context, req *pluginv1.PluginRequest) (*pluginv1.PluginResponse, error)
 }
 
-// Register adds all 15 marketplace tools and 5 prompts to the builder.
+// Register adds all 40 marketplace tools and 5 prompts to the builder. It
+// keeps .claude/ content in sync with storage for the life of the process;
+// use RegisterContext to stop when the plugin shuts down.
 func Register(builder *plugin.PluginBuilder, sender Sender, workspace string) {
-	store := storage.NewPackStorage(sender)
+	RegisterContext(context.Background(), builder, sender, workspace)
+}
+
+// RegisterContext is like Register but stops watching .claude/ when ctx is
+// done.
+func RegisterContext(ctx context.Context, builder *plugin.PluginBuilder, sender Sender, workspace string) {
+	register(ctx, builder, storage.NewPackStorage(sender), workspace)
+}
+
+// RegisterLocal is like Register but keeps storage on disk under
+// <workspace>/.projects instead of routing it through the orchestrator, for
+// the CLI and tests that run without one.
+func RegisterLocal(builder *plugin.PluginBuilder, workspace string) {
+	backend := storage.NewFileBackend(filepath.Join(workspace, ".projects"))
+	register(context.Background(), builder, storage.NewPackStorageWithBackend(backend), workspace)
+}
+
+func register(ctx context.Context, builder *plugin.PluginBuilder, store *storage.PackStorage, workspace string) {
 	mp := &internal.MarketplacePlugin{
 		Storage:   store,
 		Workspace: workspace,
@@ -28,62 +48,71 @@ func Register(builder *plugin.PluginBuilder, sender Sender, workspace string) {
 	mp.RegisterTools(builder)
 	mp.RegisterPrompts(builder)
 
-	// On startup, import .claude/skills/ and .claude/agents/ into Orchestra storage
-	// so they appear in the web UI and get synced to cloud.
-	go importClaudeSkillsAndAgents(store, workspace)
+	// Sync .claude/ skills, agents, hooks, and workflows with Orchestra
+	// storage so they appear in the web UI and get synced to cloud, and
+	// storage edits reach the files. After that, file changes are imported as they happen.
+	go watchClaudeContent(ctx, store, workspace, mp.Lock)
 }
 
-// importClaudeSkillsAndAgents scans .claude/skills/ and .claude/agents/ on startup
-// and writes them into Orchestra storage (.skills/{slug}.md / .agents/{slug}.md).
-// Always overwrites existing entries so content stays in sync with the filesystem.
-func importClaudeSkillsAndAgents(ps *storage.PackStorage, workspace string) {
-	ctx := context.Background()
+// syncLockWait is how long a background sync waits for a pack operation
+// that already holds the workspace.
+const syncLockWait = 2 * time.Minute
 
-	for _, skill := range packs.ScanClaudeSkills(workspace) {
-		path := ".skills/" + skill.Slug + ".md"
-		// Read existing entry to get current version (for optimistic lock).
-		var version int64
-		if existing, err := ps.StorageRead(ctx, path); err == nil {
-			version = existing.Version
-		}
-		meta, err := structpb.NewStruct(map[string]any{
-			"name":        skill.Name,
-			"slug":        skill.Slug,
-			"description": skill.Description,
-			"scope":       "personal",
-			"created_at":  helpers.NowISO(),
-		})
-		if err != nil {
-			continue
+// watchClaudeContent runs a two-way sync on startup, then imports, updates,
+// and deletes storage entries as files under .claude/ change, until ctx is
+// done. The watcher starts first so that changes made during the startup
+// sync are not missed; the workspace lock keeps the two apart.
+func watchClaudeContent(ctx context.Context, ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock) {
+	go syncClaudeContent(ctx, ps, workspace, lock, "startup sync", packs.SyncOptions{Direction: packs.SyncBoth, OnConflict: packs.ConflictSkip})
+
+	err := packs.WatchContent(ctx, workspace, packs.DefaultWatchDebounce, func(changes []packs.ContentChange) {
+		names := make(map[string][]string)
+		whole := make(map[string]bool)
+		for _, c := range changes {
+			if c.Slug == "" {
+				whole[c.Kind] = true
+			} else {
+				names[c.Kind] = append(names[c.Kind], c.Slug)
+			}
 		}
-		// Strip SKILL.md frontmatter — store only the markdown body as content.
-		body := packs.StripFrontmatter(skill.Content)
-		if _, err := ps.StorageWrite(ctx, path, meta, []byte(body), version); err != nil {
-			log.Printf("import skill %s: %v", skill.Slug, err)
+		for _, kind := range packs.SyncKinds {
+			if !whole[kind] && len(names[kind]) == 0 {
+				continue
+			}
+			opts := packs.SyncOptions{Direction: packs.SyncToStorage, OnConflict: packs.ConflictSkip, Types: []string{kind}}
+			if !whole[kind] {
+				opts.Names = names[kind]
+			}
+			syncClaudeContent(ctx, ps, workspace, lock, "content watch", opts)
 		}
+	})
+	if err != nil {
+		log.Printf("content watch: %v", err)
 	}
+}
 
-	for _, agent := range packs.ScanClaudeAgents(workspace) {
-		path := ".agents/" + agent.Slug + ".md"
-		// Read existing entry to get current version (for optimistic lock).
-		var version int64
-		if existing, err := ps.StorageRead(ctx, path); err == nil {
-			version = existing.Version
-		}
-		meta, err := structpb.NewStruct(map[string]any{
-			"name":        agent.Name,
-			"slug":        agent.Slug,
-			"description": agent.Description,
-			"scope":       "personal",
-			"created_at":  helpers.NowISO(),
-		})
-		if err != nil {
-			continue
+// syncClaudeContent runs one sync under the workspace lock. Conflicts are
+// left alone and logged; sync_content resolves them.
+func syncClaudeContent(ctx context.Context, ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock, operation string, opts packs.SyncOptions) {
+	release, err := lock.Acquire(ctx, operation, syncLockWait)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("content sync: %v", err)
 		}
-		// Strip agent .md frontmatter — store only the markdown body as content.
-		body := packs.StripFrontmatter(agent.Content)
-		if _, err := ps.StorageWrite(ctx, path, meta, []byte(body), version); err != nil {
-			log.Printf("import agent %s: %v", agent.Slug, err)
+		return
+	}
+	defer release()
+
+	actions, err := tools.SyncWorkspaceContent(ctx, ps, workspace, opts, false)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("content sync: %v", err)
+	}
+	for _, a := range actions {
+		switch {
+		case a.Err != nil:
+			log.Printf("content sync: %s %s %s: %v", a.Op, a.Kind, a.Slug, a.Err)
+		case a.Op == packs.SyncConflict:
+			log.Printf("content sync: %s %s: %s; run sync_content to resolve", a.Kind, a.Slug, a.Reason)
 		}
 	}
 }
@@ -0,0 +1,231 @@
+package packs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maxNoteCommits caps the git log fallback so a long history stays readable.
+const maxNoteCommits = 20
+
+// contentDirs are the pack directories whose changes the git log fallback
+// summarises.
+var contentDirs = []string{"skills", "agents", "hooks", "workflow"}
+
+// ChangelogEntry is one released version's section of a CHANGELOG.md.
+type ChangelogEntry struct {
+	Version string
+	Date    string
+	Body    string
+}
+
+// changelogHeading matches "## [1.2.0] - 2026-01-05", "## v1.2.0", and
+// "## 1.2.0 (2026-01-05)".
+var changelogHeading = regexp.MustCompile(`^##\s+\[?v?(\d+\.\d+\.\d+[0-9A-Za-z.+-]*)\]?\s*(?:[-–—(]\s*([^)]*?)\)?\s*)?$`)
+
+// ParseChangelog splits a Keep a Changelog style document into its version
+// sections. Sections without a semantic version (e.g. "Unreleased") are
+// skipped.
+func ParseChangelog(data []byte) []ChangelogEntry {
+	var entries []ChangelogEntry
+	var body []string
+	current := -1
+	flush := func() {
+		if current >= 0 {
+			entries[current].Body = strings.TrimSpace(strings.Join(body, "\n"))
+		}
+		body = nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, "## ") {
+			flush()
+			current = -1
+			if m := changelogHeading.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				entries = append(entries, ChangelogEntry{Version: m[1], Date: m[2]})
+				current = len(entries) - 1
+			}
+			continue
+		}
+		if current >= 0 {
+			body = append(body, line)
+		}
+	}
+	flush()
+	return entries
+}
+
+// ChangesBetween returns the entries newer than from and no newer than to,
+// newest first. If from is not a version, only the entry for to is returned.
+func ChangesBetween(entries []ChangelogEntry, from, to string) []ChangelogEntry {
+	upper, err := ParseVersion(to)
+	if err != nil {
+		return nil
+	}
+	lower, lowerErr := ParseVersion(from)
+
+	var out []ChangelogEntry
+	for _, e := range entries {
+		v, err := ParseVersion(e.Version)
+		if err != nil || v.Compare(upper) > 0 {
+			continue
+		}
+		if lowerErr != nil {
+			if v.Compare(upper) == 0 {
+				out = append(out, e)
+			}
+			continue
+		}
+		if v.Compare(lower) > 0 {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// ReleaseNotes describes what changed between an installed pack and a newer
+// fetched version, from the pack's changelog or, failing that, its git log.
+type ReleaseNotes struct {
+	Source  string           // "changelog" or "git"
+	Entries []ChangelogEntry // changelog sections, newest first
+	Commits []string         // "<short hash> <subject>" lines touching content
+	Files   []string         // "<status>\t<path>" for changed content files
+}
+
+// Empty reports whether there is nothing to show.
+func (n *ReleaseNotes) Empty() bool {
+	return n == nil || (len(n.Entries) == 0 && len(n.Commits) == 0 && len(n.Files) == 0)
+}
+
+// Markdown renders the notes for tool output.
+func (n *ReleaseNotes) Markdown() string {
+	if n.Empty() {
+		return "_No release notes available._\n"
+	}
+	var b strings.Builder
+	for _, e := range n.Entries {
+		if e.Date != "" {
+			fmt.Fprintf(&b, "**%s** (%s)\n\n", e.Version, e.Date)
+		} else {
+			fmt.Fprintf(&b, "**%s**\n\n", e.Version)
+		}
+		if e.Body != "" {
+			fmt.Fprintf(&b, "%s\n\n", e.Body)
+		}
+	}
+	if len(n.Commits) > 0 {
+		fmt.Fprintf(&b, "Commits:\n")
+		for _, c := range n.Commits {
+			fmt.Fprintf(&b, "- %s\n", c)
+		}
+		fmt.Fprintf(&b, "\n")
+	}
+	if len(n.Files) > 0 {
+		fmt.Fprintf(&b, "Changed files:\n")
+		for _, f := range n.Files {
+			status, path, _ := strings.Cut(f, "\t")
+			fmt.Fprintf(&b, "- `%s` %s\n", status, path)
+		}
+	}
+	return b.String()
+}
+
+// ReleaseNotes describes the changes from the installed version (fromVersion
+// at fromCommit) to this fetched version. It uses the changelog referenced by
+// pack.json when there is one, and otherwise summarises the git history of
+// the pack's content directories.
+func (f *FetchedPack) ReleaseNotes(ctx context.Context, fromVersion, fromCommit string) (*ReleaseNotes, error) {
+	if f.Manifest.Changelog != "" && filepath.IsLocal(f.Manifest.Changelog) {
+		data, err := os.ReadFile(filepath.Join(f.Dir, f.Manifest.Changelog))
+		if err == nil {
+			entries := ChangesBetween(ParseChangelog(data), fromVersion, f.Manifest.Version)
+			if len(entries) > 0 {
+				return &ReleaseNotes{Source: "changelog", Entries: entries}, nil
+			}
+		}
+	}
+	if fromCommit == "" {
+		return &ReleaseNotes{Source: "git"}, nil
+	}
+	return f.gitNotes(ctx, fromCommit)
+}
+
+// gitNotes summarises commits and file changes under the content directories
+// since fromCommit, deepening the shallow clone if needed.
+func (f *FetchedPack) gitNotes(ctx context.Context, fromCommit string) (*ReleaseNotes, error) {
+	git := func(args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, "git", append([]string{"-C", f.Dir}, args...)...).Output()
+	}
+	if _, err := git("cat-file", "-e", fromCommit+"^{commit}"); err != nil {
+		git("fetch", "-q", "--unshallow")
+		if _, err := git("cat-file", "-e", fromCommit+"^{commit}"); err != nil {
+			return nil, fmt.Errorf("installed commit %s is not in the history of %s", ShortCommit(fromCommit), f.Repo)
+		}
+	}
+
+	notes := &ReleaseNotes{Source: "git"}
+	span := fromCommit + "..HEAD"
+	logArgs := append([]string{"log", "--no-merges", fmt.Sprintf("--max-count=%d", maxNoteCommits), "--format=%h %s", span, "--"}, contentDirs...)
+	out, err := git(logArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	notes.Commits = nonEmptyLines(out)
+
+	diffArgs := append([]string{"diff", "--name-status", fromCommit, "HEAD", "--"}, contentDirs...)
+	out, err = git(diffArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("git diff: %w", err)
+	}
+	notes.Files = nonEmptyLines(out)
+	return notes, nil
+}
+
+func nonEmptyLines(out []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// CollectReleaseNotes fetches the wanted version of every outdated pack in
+// reports and returns its release notes keyed by pack name. Packs whose
+// notes could not be produced are omitted.
+func CollectReleaseNotes(ctx context.Context, reports []OutdatedReport, workers int) map[string]*ReleaseNotes {
+	var outdated []OutdatedReport
+	for _, r := range reports {
+		if r.Status == StatusOutdated {
+			outdated = append(outdated, r)
+		}
+	}
+	notes := make([]*ReleaseNotes, len(outdated))
+	parallel(len(outdated), workers, func(i int) {
+		r := outdated[i]
+		f, err := FetchPack(ctx, r.Repo, r.WantedRef)
+		if err != nil {
+			return
+		}
+		defer f.Cleanup()
+		notes[i], _ = f.ReleaseNotes(ctx, r.Version, r.Commit)
+	})
+
+	byName := make(map[string]*ReleaseNotes, len(outdated))
+	for i, r := range outdated {
+		if notes[i] != nil {
+			byName[r.Name] = notes[i]
+		}
+	}
+	return byName
+}
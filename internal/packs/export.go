@@ -0,0 +1,556 @@
+package packs
+
+import (
+	"archive/tar"
+	"cmp"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of exported content.
+const (
+	SourceWorkspace = "workspace"
+	SourceStorage   = "storage"
+)
+
+// ExportItem is one piece of content that can be exported into a pack.
+type ExportItem struct {
+	Type    string // ContentSkills, ContentAgents, ContentHooks, or ContentWorkflows
+	Slug    string // content name; for workflows, the file name
+	Source  string // SourceWorkspace or SourceStorage
+	Path    string // file, or skill directory, on disk (workspace content)
+	Content string // full file content (storage content)
+	Pack    string // installed pack that provided the content, if any
+}
+
+// Key identifies the item across sources, e.g. "skills/go-backend".
+func (it ExportItem) Key() string {
+	return it.Type + "/" + it.Slug
+}
+
+// CollectWorkspaceContent returns the skills, agents, hooks, and workflows
+// under the workspace's .claude directory.
+func CollectWorkspaceContent(workspace string) []ExportItem {
+	claudeDir := filepath.Join(workspace, ".claude")
+	var items []ExportItem
+	for _, name := range ListInstalledSkills(workspace) {
+		items = append(items, ExportItem{Type: ContentSkills, Slug: name, Source: SourceWorkspace, Path: filepath.Join(claudeDir, "skills", name)})
+	}
+	for _, name := range ListInstalledAgents(workspace) {
+		items = append(items, ExportItem{Type: ContentAgents, Slug: name, Source: SourceWorkspace, Path: filepath.Join(claudeDir, "agents", name+".md")})
+	}
+	for _, name := range ListInstalledHooks(workspace) {
+		items = append(items, ExportItem{Type: ContentHooks, Slug: name, Source: SourceWorkspace, Path: filepath.Join(claudeDir, "hooks", name+".sh")})
+	}
+	entries, _ := os.ReadDir(filepath.Join(claudeDir, "workflows"))
+	for _, e := range entries {
+		if !e.IsDir() && workflowPattern.MatchString(e.Name()) {
+			items = append(items, ExportItem{Type: ContentWorkflows, Slug: e.Name(), Source: SourceWorkspace, Path: filepath.Join(claudeDir, "workflows", e.Name())})
+		}
+	}
+	return items
+}
+
+// StoredItem builds an export item from a storage entry (.skills/, .agents/,
+// or .hooks/). Skills and agents are stored without their frontmatter, so it
+// is rebuilt from the entry's metadata.
+func StoredItem(contentType, slug string, metadata map[string]any, content []byte) ExportItem {
+	body := string(content)
+	switch contentType {
+	case ContentSkills:
+		body = RestoreFrontmatter(body, slug, metadata, skillFields)
+	case ContentAgents:
+		body = RestoreFrontmatter(body, slug, metadata, agentFields)
+	}
+	return ExportItem{Type: contentType, Slug: slug, Source: SourceStorage, Content: body}
+}
+
+// RestoreFrontmatter prepends YAML frontmatter to a markdown body, the
+// reverse of StripFrontmatter. The name is always the slug; other fields are
+// taken from metadata when listed in allowed. A body that already has
+// frontmatter is returned unchanged.
+func RestoreFrontmatter(body, slug string, metadata map[string]any, allowed []string) string {
+	if strings.HasPrefix(body, "---\n") || strings.HasPrefix(body, "---\r\n") {
+		return body
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value any) {
+		var v yaml.Node
+		if err := v.Encode(value); err != nil {
+			return
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &v)
+	}
+	add("name", slug)
+	for _, key := range allowed {
+		if key == "name" {
+			continue
+		}
+		if value, ok := metadata[key]; ok && value != nil && value != "" {
+			add(key, value)
+		}
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return body
+	}
+	return "---\n" + string(out) + "---\n\n" + body
+}
+
+// MergeExportItems combines workspace and storage content. Workspace copies
+// win, since they keep a skill's supporting files and the original
+// frontmatter.
+func MergeExportItems(workspace, stored []ExportItem) []ExportItem {
+	seen := make(map[string]bool, len(workspace))
+	merged := slices.Clone(workspace)
+	for _, it := range workspace {
+		seen[it.Key()] = true
+	}
+	for _, it := range stored {
+		if !seen[it.Key()] {
+			seen[it.Key()] = true
+			merged = append(merged, it)
+		}
+	}
+	return merged
+}
+
+// ExportSelection chooses which content to export. Patterns are slugs or
+// path.Match globs keyed by content type; Packs selects everything an
+// installed pack provided. With no patterns and no packs, all content is
+// selected.
+type ExportSelection struct {
+	Patterns          map[string][]string
+	Packs             []string
+	IncludeThirdParty bool // export content owned by installed packs
+}
+
+// SkippedItem is content that matched the selection but was left out.
+type SkippedItem struct {
+	Item   ExportItem
+	Reason string
+}
+
+// SelectExport applies a selection to the collected items. Content provided
+// by an installed pack is skipped unless its pack is selected or
+// IncludeThirdParty is set. A literal slug or pack that matches nothing is
+// an error.
+func SelectExport(items []ExportItem, sel ExportSelection) ([]ExportItem, []SkippedItem, error) {
+	all := len(sel.Packs) == 0
+	for _, patterns := range sel.Patterns {
+		if len(patterns) > 0 {
+			all = false
+		}
+	}
+	for typ, patterns := range sel.Patterns {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return nil, nil, fmt.Errorf("invalid %s pattern %q: %w", typ, p, err)
+			}
+		}
+	}
+
+	matched := make(map[string]bool)
+	var selected []ExportItem
+	var skipped []SkippedItem
+	for _, it := range items {
+		byPack := it.Pack != "" && slices.Contains(sel.Packs, it.Pack)
+		if byPack {
+			matched[it.Pack] = true
+		}
+		byPattern := false
+		for _, p := range sel.Patterns[it.Type] {
+			if ok, _ := path.Match(p, it.Slug); ok {
+				matched[it.Type+":"+p] = true
+				byPattern = true
+			}
+		}
+		switch {
+		case !all && !byPack && !byPattern:
+			continue
+		case it.Pack != "" && !byPack && !sel.IncludeThirdParty:
+			skipped = append(skipped, SkippedItem{it, fmt.Sprintf("installed from %s", it.Pack)})
+		case !exportableSlug(it):
+			skipped = append(skipped, SkippedItem{it, "name is not a valid pack content name"})
+		default:
+			selected = append(selected, it)
+		}
+	}
+
+	for typ, patterns := range sel.Patterns {
+		for _, p := range patterns {
+			if !matched[typ+":"+p] && !strings.ContainsAny(p, "*?[") {
+				return nil, nil, fmt.Errorf("%s %q not found", strings.TrimSuffix(typ, "s"), p)
+			}
+		}
+	}
+	for _, name := range sel.Packs {
+		if !matched[name] {
+			return nil, nil, fmt.Errorf("no installed content from pack %q", name)
+		}
+	}
+	return selected, skipped, nil
+}
+
+// exportableSlug reports whether the item's name passes manifest validation.
+func exportableSlug(it ExportItem) bool {
+	if it.Type == ContentWorkflows {
+		return workflowPattern.MatchString(it.Slug)
+	}
+	return slugPattern.MatchString(it.Slug)
+}
+
+// ExportOptions describes the pack generated by ExportPack. Description,
+// license, stacks, and tags fall back to those of a previous export in the
+// same directory.
+type ExportOptions struct {
+	Name        string // owner/slug pack name
+	Description string
+	License     string
+	Stacks      []string
+	Tags        []string
+	Version     string // exact version to write
+	Bump        string // "major", "minor", or "patch" applied to the previous export's version
+	// HookEvents is the wiring to declare for exported hooks, by hook name.
+	HookEvents map[string]HookEvent
+}
+
+// ExportPack writes the selected items into dir as a pack with a generated
+// pack.json. dir must be missing, empty, or a previous export (it contains
+// a pack.json); a previous export's content is replaced, and its README and
+// CHANGELOG are kept with a CHANGELOG entry added for the new version. It
+// returns the manifest and the pack-relative paths of the files written.
+func ExportPack(dir string, items []ExportItem, opts ExportOptions) (*PackManifest, []string, error) {
+	if !packNamePattern.MatchString(opts.Name) {
+		return nil, nil, fmt.Errorf("name %q must be owner/slug in lowercase letters, digits, and dashes (e.g. \"acme/pack-go-tools\")", opts.Name)
+	}
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("nothing to export")
+	}
+	if opts.Version != "" && opts.Bump != "" {
+		return nil, nil, fmt.Errorf("pass either version or bump, not both")
+	}
+
+	prev, err := previousExport(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	version, err := exportVersion(prev, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m := &PackManifest{
+		Schema:    ManifestSchemaID,
+		Name:      opts.Name,
+		Version:   version,
+		Type:      TypePack,
+		Requires:  []string{},
+		Contents:  PackContents{Skills: []string{}, Agents: []string{}, Hooks: []string{}, Workflows: []string{}},
+		Changelog: "CHANGELOG.md",
+	}
+	m.Description, m.License, m.Stacks, m.Tags = opts.Description, opts.License, opts.Stacks, opts.Tags
+	if prev != nil {
+		m.Description = cmp.Or(m.Description, prev.Description)
+		m.License = cmp.Or(m.License, prev.License)
+		if m.Stacks == nil {
+			m.Stacks = prev.Stacks
+		}
+		if m.Tags == nil {
+			m.Tags = prev.Tags
+		}
+		for _, sub := range contentDirs {
+			if err := os.RemoveAll(filepath.Join(dir, sub)); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	title := toTitleCase(strings.ReplaceAll(contentSlug(opts.Name), "-", " "))
+	m.Description = cmp.Or(m.Description, fmt.Sprintf("%s skills and agents", title))
+	m.Stacks, m.Tags = nonNil(m.Stacks), nonNil(m.Tags)
+
+	var written []string
+	for _, it := range items {
+		rel, err := writeExportItem(dir, it)
+		if err != nil {
+			return nil, written, fmt.Errorf("export %s %s: %w", strings.TrimSuffix(it.Type, "s"), it.Slug, err)
+		}
+		written = append(written, rel...)
+		switch it.Type {
+		case ContentSkills:
+			m.Contents.Skills = append(m.Contents.Skills, it.Slug)
+		case ContentAgents:
+			m.Contents.Agents = append(m.Contents.Agents, it.Slug)
+		case ContentHooks:
+			m.Contents.Hooks = append(m.Contents.Hooks, it.Slug)
+			if ev, ok := opts.HookEvents[it.Slug]; ok {
+				if m.HookEvents == nil {
+					m.HookEvents = make(map[string]HookEvent)
+				}
+				m.HookEvents[it.Slug] = ev
+			}
+		case ContentWorkflows:
+			m.Contents.Workflows = append(m.Contents.Workflows, it.Slug)
+		}
+	}
+	for _, list := range [][]string{m.Contents.Skills, m.Contents.Agents, m.Contents.Hooks, m.Contents.Workflows} {
+		slices.Sort(list)
+	}
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, written, err
+	}
+	extra := map[string]string{"pack.json": string(manifest) + "\n"}
+	if _, err := os.Stat(filepath.Join(dir, "README.md")); err != nil {
+		extra["README.md"] = readmeTemplate(m, title)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "CHANGELOG.md")); err != nil {
+		extra["CHANGELOG.md"] = changelogTemplate(version)
+	} else if !slices.ContainsFunc(ParseChangelog(data), func(e ChangelogEntry) bool { return e.Version == version }) {
+		extra["CHANGELOG.md"] = addChangelogEntry(string(data), version)
+	}
+	for rel, content := range extra {
+		if err := os.WriteFile(filepath.Join(dir, rel), []byte(content), 0644); err != nil {
+			return nil, written, err
+		}
+		written = append(written, rel)
+	}
+	slices.Sort(written)
+
+	if _, err := ReadManifest(dir); err != nil {
+		return nil, written, fmt.Errorf("exported pack is invalid: %w", err)
+	}
+	return m, written, nil
+}
+
+// previousExport returns the manifest of an earlier export in dir, or nil
+// when dir is missing or empty. Any other non-empty directory is refused.
+func previousExport(dir string) (*PackManifest, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) == 0 {
+		return nil, os.MkdirAll(dir, 0755)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "pack.json"))
+	if err != nil {
+		return nil, fmt.Errorf("%s is not empty and has no pack.json to update", dir)
+	}
+	var m PackManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("read previous pack.json: %w", err)
+	}
+	return &m, nil
+}
+
+// exportVersion picks the version for an export: the requested version, the
+// previous version bumped, the previous version unchanged, or 0.1.0.
+func exportVersion(prev *PackManifest, opts ExportOptions) (string, error) {
+	if opts.Version != "" {
+		v, err := ParseVersion(opts.Version)
+		if err != nil {
+			return "", err
+		}
+		return v.String(), nil
+	}
+	if prev == nil || prev.Version == "" {
+		if opts.Bump != "" {
+			return "", fmt.Errorf("no previous export to bump; pass version instead")
+		}
+		return "0.1.0", nil
+	}
+	if opts.Bump == "" {
+		return prev.Version, nil
+	}
+	v, err := ParseVersion(prev.Version)
+	if err != nil {
+		return "", fmt.Errorf("previous export: %w", err)
+	}
+	bumped, err := v.Bump(opts.Bump)
+	if err != nil {
+		return "", err
+	}
+	return bumped.String(), nil
+}
+
+// writeExportItem writes one item in pack layout and returns the
+// pack-relative paths it wrote.
+func writeExportItem(dir string, it ExportItem) ([]string, error) {
+	var rel string
+	switch it.Type {
+	case ContentSkills:
+		if it.Source == SourceWorkspace {
+			return copyExportDir(it.Path, dir, filepath.Join("skills", it.Slug))
+		}
+		rel = filepath.Join("skills", it.Slug, "SKILL.md")
+	case ContentAgents:
+		rel = filepath.Join("agents", it.Slug+".md")
+	case ContentHooks:
+		rel = filepath.Join("hooks", it.Slug+".sh")
+	case ContentWorkflows:
+		rel = filepath.Join("workflow", it.Slug)
+	default:
+		return nil, fmt.Errorf("unknown content type %q", it.Type)
+	}
+
+	data := []byte(it.Content)
+	if it.Source == SourceWorkspace {
+		var err error
+		if data, err = os.ReadFile(it.Path); err != nil {
+			return nil, err
+		}
+	}
+	mode := os.FileMode(0644)
+	if it.Type == ContentHooks {
+		mode = 0755
+	}
+	full := filepath.Join(dir, rel)
+	if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(full, data, mode); err != nil {
+		return nil, err
+	}
+	return []string{filepath.ToSlash(rel)}, nil
+}
+
+// copyExportDir copies a skill directory into the pack, skipping dotfiles
+// that manifest validation would report as stray.
+func copyExportDir(src, dir, rel string) ([]string, error) {
+	var written []string
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != src && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		sub, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(rel, sub)
+		if err := copyFile(p, filepath.Join(dir, target)); err != nil {
+			return err
+		}
+		written = append(written, filepath.ToSlash(target))
+		return nil
+	})
+	return written, err
+}
+
+// addChangelogEntry inserts a section for version above the newest released
+// section of an existing CHANGELOG.md.
+func addChangelogEntry(changelog, version string) string {
+	entry := fmt.Sprintf("## [%s] - %s\n\n- Exported from workspace\n\n", version, time.Now().UTC().Format("2006-01-02"))
+	lines := strings.SplitAfter(changelog, "\n")
+	for i, line := range lines {
+		if changelogHeading.MatchString(strings.TrimRight(line, "\r\n")) {
+			return strings.Join(lines[:i], "") + entry + strings.Join(lines[i:], "")
+		}
+	}
+	if !strings.HasSuffix(changelog, "\n") {
+		changelog += "\n"
+	}
+	return changelog + "\n" + strings.TrimSuffix(entry, "\n")
+}
+
+// ArchivePack writes dir as a gzipped tarball at dest. Entries are prefixed
+// with the directory's base name so the archive unpacks into one folder. An
+// existing file at dest is only replaced if it is a previous archive of the
+// same directory, so a mistyped path cannot overwrite an unrelated file.
+func ArchivePack(dir, dest string) (err error) {
+	root := filepath.Base(dir)
+	flag := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if info, err := os.Lstat(dest); err == nil {
+		if !info.Mode().IsRegular() || !isPackArchive(dest, root) {
+			return fmt.Errorf("%s already exists and is not a previous export of %s", dest, root)
+		}
+		flag = os.O_WRONLY | os.O_TRUNC
+	}
+	f, err := os.OpenFile(dest, flag, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(root, filepath.ToSlash(rel))
+		if d.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// isPackArchive reports whether path is a gzipped tarball that ArchivePack
+// wrote for a directory named root.
+func isPackArchive(path, root string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return false
+	}
+	hdr, err := tar.NewReader(gz).Next()
+	return err == nil && hdr.Name == root+"/"
+}
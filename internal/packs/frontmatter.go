@@ -0,0 +1,299 @@
+package packs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter is the YAML header of a skill (SKILL.md) or agent file.
+// Fields the agent runtime defines are typed; anything else is kept in
+// Extra. List fields accept either a YAML sequence or a comma-separated
+// string ("Read, Grep, Glob").
+type Frontmatter struct {
+	Name            string
+	Description     string
+	Model           string
+	License         string   // skills
+	Version         string   // skills
+	ArgumentHint    string   // skills: argument-hint
+	AllowedTools    []string // skills: allowed-tools
+	Tools           []string // agents
+	DisallowedTools []string // agents: disallowedTools
+	Color           string   // agents
+	PermissionMode  string   // agents: permissionMode
+	Skills          []string // agents
+
+	// Extra holds the fields without a typed counterpart, as plain values.
+	Extra map[string]any
+
+	keys   []string       // field names in file order
+	fields map[string]any // every field as written, as plain values
+}
+
+// Metadata returns every frontmatter field as written, keyed by its YAML
+// name, in a form that can be stored as storage metadata.
+func (f *Frontmatter) Metadata() map[string]any {
+	return maps.Clone(f.fields)
+}
+
+// Keys returns the field names in the order they appear in the file.
+func (f *Frontmatter) Keys() []string {
+	return f.keys
+}
+
+// ParseFrontmatter splits a markdown document into its YAML frontmatter and
+// body. A document without frontmatter returns nil and the content
+// unchanged. When the delimiters are present but the YAML is invalid, the
+// body is still split off and the error is returned.
+func ParseFrontmatter(content string) (*Frontmatter, string, error) {
+	block, body, _, ok := splitFrontmatter(content)
+	if !ok {
+		return nil, content, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(block), &doc); err != nil {
+		return nil, body, err
+	}
+	fm := &Frontmatter{Extra: map[string]any{}, fields: map[string]any{}}
+	if len(doc.Content) == 0 {
+		return fm, body, nil // "---\n---": empty frontmatter
+	}
+	mapping := doc.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, body, fmt.Errorf("frontmatter must be a mapping of fields")
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, val := mapping.Content[i].Value, mapping.Content[i+1]
+		var raw any
+		if val.Kind == yaml.ScalarNode && val.Tag == "!!timestamp" {
+			raw = val.Value // keep dates as written
+		} else if err := val.Decode(&raw); err != nil {
+			return nil, body, fmt.Errorf("%s: %w", key, err)
+		}
+		if _, dup := fm.fields[key]; !dup {
+			fm.keys = append(fm.keys, key)
+		}
+		fm.fields[key] = plainValue(raw)
+
+		switch key {
+		case "name":
+			fm.Name = scalarString(val)
+		case "description":
+			fm.Description = scalarString(val)
+		case "model":
+			fm.Model = scalarString(val)
+		case "license":
+			fm.License = scalarString(val)
+		case "version":
+			fm.Version = scalarString(val)
+		case "argument-hint":
+			fm.ArgumentHint = scalarString(val)
+		case "allowed-tools":
+			fm.AllowedTools = stringList(val)
+		case "tools":
+			fm.Tools = stringList(val)
+		case "disallowedTools":
+			fm.DisallowedTools = stringList(val)
+		case "color":
+			fm.Color = scalarString(val)
+		case "permissionMode":
+			fm.PermissionMode = scalarString(val)
+		case "skills":
+			fm.Skills = stringList(val)
+		default:
+			fm.Extra[key] = fm.fields[key]
+		}
+	}
+	return fm, body, nil
+}
+
+// RenderFrontmatter writes fields as YAML frontmatter above body. When
+// previous is the file being replaced and the fields are what it already
+// has, its frontmatter is kept byte for byte. Otherwise fields it has keep
+// their position and comments, and their formatting while their value is
+// unchanged, so a block scalar description stays a block scalar. New fields
+// follow, name and description first and the rest sorted.
+func RenderFrontmatter(previous string, fields map[string]any, body string) (string, error) {
+	oldKeys := map[string]*yaml.Node{}
+	oldVals := map[string]*yaml.Node{}
+	var order []string
+	block, _, _, hasPrevious := splitFrontmatter(previous)
+	changed := !hasPrevious
+	if hasPrevious {
+		var doc yaml.Node
+		if yaml.Unmarshal([]byte(block), &doc) == nil && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+			m := doc.Content[0]
+			for i := 0; i+1 < len(m.Content); i += 2 {
+				key := m.Content[i].Value
+				if _, ok := fields[key]; !ok || oldKeys[key] != nil {
+					changed = true // a removed or duplicate field
+					continue
+				}
+				oldKeys[key], oldVals[key] = m.Content[i], m.Content[i+1]
+				order = append(order, key)
+			}
+		} else {
+			changed = true
+		}
+	}
+	var added []string
+	for key := range fields {
+		if oldKeys[key] == nil {
+			added = append(added, key)
+		}
+	}
+	first := map[string]int{"name": 1, "description": 2}
+	slices.SortFunc(added, func(a, b string) int {
+		ra, rb := first[a], first[b]
+		switch {
+		case ra != 0 && rb != 0:
+			return ra - rb
+		case ra != 0:
+			return -1
+		case rb != 0:
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	order = append(order, added...)
+
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, key := range order {
+		if old := oldVals[key]; old != nil && sameValue(old, fields[key]) {
+			mapping.Content = append(mapping.Content, oldKeys[key], old)
+			continue
+		}
+		changed = true
+		var val yaml.Node
+		if err := val.Encode(fields[key]); err != nil {
+			return "", fmt.Errorf("%s: %w", key, err)
+		}
+		keyNode := oldKeys[key]
+		if keyNode == nil {
+			keyNode = &yaml.Node{Kind: yaml.ScalarNode, Value: key}
+		}
+		mapping.Content = append(mapping.Content, keyNode, &val)
+	}
+	if !changed {
+		return "---\n" + block + "---\n\n" + body, nil
+	}
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(mapping); err != nil {
+		return "", err
+	}
+	enc.Close()
+	return "---\n" + out.String() + "---\n\n" + body, nil
+}
+
+// sameValue reports whether a YAML node decodes to value, comparing their
+// JSON forms so integers and the floats storage returns compare equal.
+func sameValue(n *yaml.Node, value any) bool {
+	var decoded any
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!timestamp" {
+		decoded = n.Value
+	} else if n.Decode(&decoded) != nil {
+		return false
+	}
+	a, errA := json.Marshal(plainValue(decoded))
+	b, errB := json.Marshal(value)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// splitFrontmatter finds a frontmatter block: a first line of "---" and the
+// next line that is exactly "---". It returns the YAML between them, the body
+// after the closing line with one blank line dropped, and the number of file
+// lines before the YAML, to offset YAML line numbers by. A fenced code block
+// before the closing delimiter means the document has no frontmatter, so a
+// "---" inside code is never taken for one.
+func splitFrontmatter(content string) (block, body string, offset int, ok bool) {
+	content = strings.TrimPrefix(content, "\ufeff")
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) == 0 || strings.TrimRight(lines[0], "\r\n") != "---" {
+		return "", "", 0, false
+	}
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r\n")
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			return "", "", 0, false
+		}
+		if line != "---" {
+			continue
+		}
+		rest := lines[i+1:]
+		if len(rest) > 0 && strings.TrimRight(rest[0], "\r\n") == "" {
+			rest = rest[1:]
+		}
+		return strings.Join(lines[1:i], ""), strings.Join(rest, ""), 1, true
+	}
+	return "", "", 0, false
+}
+
+// scalarString returns a scalar field's value, or "" for other kinds.
+func scalarString(n *yaml.Node) string {
+	if n.Kind != yaml.ScalarNode || n.Tag == "!!null" {
+		return ""
+	}
+	return strings.TrimSpace(n.Value)
+}
+
+// stringList reads a list field written as a sequence or as a
+// comma-separated string.
+func stringList(n *yaml.Node) []string {
+	var list []string
+	switch n.Kind {
+	case yaml.ScalarNode:
+		for _, item := range strings.Split(scalarString(n), ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if s := scalarString(item); s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
+
+// plainValue converts a decoded YAML value to strings, numbers, booleans,
+// and string-keyed maps and slices of them, which JSON and storage metadata
+// can hold.
+func plainValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[k] = plainValue(val)
+		}
+		return out
+	case map[any]any:
+		out := make(map[string]any, len(v))
+		for k, val := range v {
+			out[fmt.Sprint(k)] = plainValue(val)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, val := range v {
+			out[i] = plainValue(val)
+		}
+		return out
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case nil, string, bool, int, int64, uint64, float64:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
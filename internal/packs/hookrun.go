@@ -0,0 +1,438 @@
+package packs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits for test runs of hooks.
+const (
+	DefaultHookTestTimeout = 10 * time.Second
+	MaxHookTestTimeout     = 60 * time.Second
+	hookOutputLimit        = 64 << 10 // bytes kept per output stream
+)
+
+// HookTest describes one test run of a hook script.
+type HookTest struct {
+	Name     string // hook name; the script runs as .claude/hooks/<name>.sh
+	Event    string // one of KnownHookEvents
+	Matcher  string // declared matcher, used to pick a tool for tool events
+	ToolName string // tool for tool events (default: from the matcher, else Bash)
+	// Payload is merged over the synthetic event payload, replacing top-level
+	// fields.
+	Payload map[string]any
+	Timeout time.Duration // default DefaultHookTestTimeout, capped at MaxHookTestTimeout
+}
+
+// HookRun is the result of running a hook in the sandbox.
+type HookRun struct {
+	ExitCode  int // -1 if the hook was killed
+	Stdout    string
+	Stderr    string
+	Truncated bool // output beyond the per-stream limit was dropped
+	Duration  time.Duration
+	TimedOut  bool
+	Payload   []byte   // the JSON passed on stdin
+	Sandbox   []string // how the run was restricted
+}
+
+// Outcome explains the exit code the way the agent runtime treats it.
+func (r *HookRun) Outcome() string {
+	switch {
+	case r.TimedOut:
+		return "timed out; the runtime would cancel the hook and continue"
+	case r.ExitCode == 0:
+		return "success"
+	case r.ExitCode == 2:
+		return "blocking error; stderr is fed back to Claude"
+	case r.ExitCode < 0:
+		return "killed"
+	default:
+		return "non-blocking error; stderr is shown to the user"
+	}
+}
+
+// RunHook runs a hook script once with a synthetic event payload on stdin.
+// The script runs in a fresh temporary project directory with a scrubbed
+// environment (PATH, HOME, TMPDIR, LANG, and CLAUDE_PROJECT_DIR only) and a
+// timeout. Where the platform allows, it also runs without network access.
+// A non-zero exit is a result, not an error.
+func RunHook(ctx context.Context, script []byte, t HookTest) (*HookRun, error) {
+	if !slices.Contains(KnownHookEvents, t.Event) {
+		return nil, fmt.Errorf("unknown hook event %q (want one of %s)", t.Event, strings.Join(KnownHookEvents, ", "))
+	}
+	if !hookNamePattern.MatchString(t.Name) {
+		return nil, fmt.Errorf("invalid hook name %q", t.Name)
+	}
+	timeout := t.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTestTimeout
+	}
+	timeout = min(timeout, MaxHookTestTimeout)
+
+	tmp, err := os.MkdirTemp("", "hook-test-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+	project := filepath.Join(tmp, "project")
+	home := filepath.Join(tmp, "home")
+	tmpDir := filepath.Join(tmp, "tmp")
+	hooksDir := filepath.Join(project, ".claude", "hooks")
+	for _, dir := range []string{hooksDir, home, tmpDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	scriptPath := filepath.Join(hooksDir, t.Name+".sh")
+	if err := os.WriteFile(scriptPath, script, 0700); err != nil {
+		return nil, err
+	}
+	transcript := filepath.Join(tmp, "transcript.jsonl")
+	if err := os.WriteFile(transcript, nil, 0600); err != nil {
+		return nil, err
+	}
+
+	payload := HookPayload(t.Event, project, transcript, t.ToolName, t.Matcher)
+	maps.Copy(payload, t.Payload)
+	input, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+
+	args := []string{scriptPath}
+	if !bytes.HasPrefix(script, []byte("#!")) {
+		args = []string{"/bin/sh", scriptPath} // what a shell does with a script lacking a shebang
+	}
+	sandbox := []string{"temporary project directory", "scrubbed environment", fmt.Sprintf("%s timeout", timeout)}
+	if prefix := networkIsolation(); prefix != nil {
+		args = append(slices.Clone(prefix), args...)
+		sandbox = append(sandbox, "no network")
+	} else {
+		sandbox = append(sandbox, "network not restricted (unshare is unavailable on this system)")
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, args[0], args[1:]...)
+	cmd.Dir = project
+	cmd.Env = hookEnv(project, home, tmpDir)
+	cmd.Stdin = bytes.NewReader(input)
+	stdout, stderr := &cappedBuffer{max: hookOutputLimit}, &cappedBuffer{max: hookOutputLimit}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.WaitDelay = time.Second
+	isolateProcess(cmd)
+
+	start := time.Now()
+	err = cmd.Run()
+	run := &HookRun{
+		Duration:  time.Since(start),
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+		Payload:   input,
+		Sandbox:   sandbox,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		run.TimedOut, run.ExitCode = true, -1
+	case err == nil:
+	case errors.As(err, &exitErr):
+		run.ExitCode = exitErr.ExitCode()
+	case errors.Is(err, exec.ErrWaitDelay):
+		// The hook exited but left a background process holding its output.
+		run.ExitCode = cmd.ProcessState.ExitCode()
+	default:
+		return nil, err
+	}
+	return run, nil
+}
+
+// hookEnv is the whole environment a test run sees.
+func hookEnv(project, home, tmpDir string) []string {
+	path := os.Getenv("PATH")
+	if path == "" {
+		path = "/usr/local/bin:/usr/bin:/bin"
+	}
+	return []string{
+		"PATH=" + path,
+		"HOME=" + home,
+		"TMPDIR=" + tmpDir,
+		"LANG=C.UTF-8",
+		"CLAUDE_PROJECT_DIR=" + project,
+	}
+}
+
+var (
+	netIsolationOnce   sync.Once
+	netIsolationPrefix []string
+)
+
+// networkIsolation returns a command prefix that runs a program in a new,
+// empty network namespace, or nil if the system cannot do that for an
+// unprivileged user. The probe runs once per process.
+func networkIsolation() []string {
+	netIsolationOnce.Do(func() {
+		unshare, err := exec.LookPath("unshare")
+		if err != nil {
+			return
+		}
+		// Prefer keeping the caller's uid; older util-linux only maps root.
+		for _, flags := range [][]string{{"--map-current-user", "--net"}, {"--map-root-user", "--net"}} {
+			prefix := append(append([]string{unshare}, flags...), "--")
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			err := exec.CommandContext(ctx, prefix[0], append(prefix[1:], "true")...).Run()
+			cancel()
+			if err == nil {
+				netIsolationPrefix = prefix
+				return
+			}
+		}
+	})
+	return netIsolationPrefix
+}
+
+// cappedBuffer keeps the first max bytes written to it.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (c *cappedBuffer) Write(p []byte) (int, error) {
+	if room := c.max - c.buf.Len(); room < len(p) {
+		c.buf.Write(p[:max(room, 0)])
+		c.truncated = true
+		return len(p), nil
+	}
+	return c.buf.Write(p)
+}
+
+func (c *cappedBuffer) String() string {
+	return c.buf.String()
+}
+
+// HookPayload builds a synthetic payload for an event, with the fields the
+// agent runtime sends on stdin. Tool events use toolName, or a tool the
+// matcher accepts when toolName is empty.
+func HookPayload(event, cwd, transcript, toolName, matcher string) map[string]any {
+	p := map[string]any{
+		"session_id":      "hook-test-session",
+		"transcript_path": transcript,
+		"cwd":             cwd,
+		"hook_event_name": event,
+	}
+	switch event {
+	case "PreToolUse", "PostToolUse":
+		if toolName == "" {
+			toolName = sampleTool(matcher)
+		}
+		p["tool_name"] = toolName
+		p["tool_input"] = sampleToolInput(toolName, cwd)
+		if event == "PostToolUse" {
+			p["tool_response"] = sampleToolResponse(toolName, cwd)
+		}
+	case "UserPromptSubmit":
+		p["prompt"] = "Write a function that adds two numbers"
+	case "Notification":
+		p["message"] = "Claude needs your permission to use Bash"
+	case "Stop", "SubagentStop":
+		p["stop_hook_active"] = false
+	case "PreCompact":
+		p["trigger"] = "manual"
+		p["custom_instructions"] = ""
+	case "SessionStart":
+		p["source"] = "startup"
+	case "SessionEnd":
+		p["reason"] = "other"
+	}
+	return p
+}
+
+var (
+	hookNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	toolNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// sampleTool picks a tool name the matcher accepts: the first plain name in
+// an alternation such as "Edit|Write", else Bash.
+func sampleTool(matcher string) string {
+	if matcher == "" || matcher == "*" {
+		return "Bash"
+	}
+	re, err := regexp.Compile("^(?:" + matcher + ")$")
+	if err != nil {
+		return "Bash"
+	}
+	for _, alt := range strings.Split(matcher, "|") {
+		if toolNamePattern.MatchString(alt) && re.MatchString(alt) {
+			return alt
+		}
+	}
+	return "Bash"
+}
+
+func sampleToolInput(tool, cwd string) map[string]any {
+	file := filepath.Join(cwd, "example.txt")
+	switch tool {
+	case "Bash":
+		return map[string]any{"command": "echo hello", "description": "Print a greeting"}
+	case "Read":
+		return map[string]any{"file_path": file}
+	case "Write":
+		return map[string]any{"file_path": file, "content": "hello\n"}
+	case "Edit":
+		return map[string]any{"file_path": file, "old_string": "hello", "new_string": "goodbye"}
+	case "MultiEdit":
+		return map[string]any{"file_path": file, "edits": []any{map[string]any{"old_string": "hello", "new_string": "goodbye"}}}
+	case "Glob":
+		return map[string]any{"pattern": "**/*.go"}
+	case "Grep":
+		return map[string]any{"pattern": "TODO"}
+	case "WebFetch":
+		return map[string]any{"url": "https://example.com", "prompt": "Summarise the page"}
+	case "WebSearch":
+		return map[string]any{"query": "example"}
+	case "Task":
+		return map[string]any{"description": "Example task", "prompt": "Summarise the README", "subagent_type": "general-purpose"}
+	default:
+		return map[string]any{}
+	}
+}
+
+func sampleToolResponse(tool, cwd string) map[string]any {
+	switch tool {
+	case "Bash":
+		return map[string]any{"stdout": "hello\n", "stderr": "", "interrupted": false}
+	case "Write", "Edit", "MultiEdit":
+		return map[string]any{"filePath": filepath.Join(cwd, "example.txt"), "success": true}
+	default:
+		return map[string]any{"success": true}
+	}
+}
+
+// HookFixture is a test case a pack ships for one of its hooks, stored as
+// fixtures/hooks/<hook>/<case>.json. lint_pack runs every fixture.
+type HookFixture struct {
+	Event   string         `json:"event,omitempty"`     // default: the hook's declared event
+	Tool    string         `json:"tool_name,omitempty"` // tool events only
+	Payload map[string]any `json:"payload,omitempty"`   // merged over the synthetic payload
+	Expect  struct {
+		ExitCode       *int   `json:"exit_code,omitempty"` // default 0
+		StdoutContains string `json:"stdout_contains,omitempty"`
+		StderrContains string `json:"stderr_contains,omitempty"`
+	} `json:"expect"`
+}
+
+// FixtureResult is the outcome of one hook fixture.
+type FixtureResult struct {
+	Hook     string
+	File     string // pack-relative, slash-separated
+	Run      *HookRun
+	Err      error    // the fixture could not be run
+	Failures []string // expectations the run did not meet
+}
+
+// RunHookFixtures runs the fixtures under fixtures/hooks/ of a pack directory
+// against its hook scripts, in file order.
+func RunHookFixtures(ctx context.Context, dir string, m *PackManifest) []FixtureResult {
+	root := filepath.Join(dir, "fixtures", "hooks")
+	hookDirs, _ := os.ReadDir(root)
+	var results []FixtureResult
+	for _, hd := range hookDirs {
+		if !hd.IsDir() {
+			continue
+		}
+		hook := hd.Name()
+		files, _ := os.ReadDir(filepath.Join(root, hook))
+		for _, f := range files {
+			if f.IsDir() || !strings.HasSuffix(f.Name(), ".json") {
+				continue
+			}
+			r := FixtureResult{Hook: hook, File: "fixtures/hooks/" + hook + "/" + f.Name()}
+			r.Run, r.Failures, r.Err = runFixture(ctx, dir, m, hook, filepath.Join(root, hook, f.Name()))
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func runFixture(ctx context.Context, dir string, m *PackManifest, hook, path string) (*HookRun, []string, error) {
+	if !slices.Contains(m.Contents.Hooks, hook) {
+		return nil, nil, fmt.Errorf("hook %q is not declared in contents.hooks", hook)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var fx HookFixture
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fx); err != nil {
+		return nil, nil, fmt.Errorf("invalid fixture: %w", err)
+	}
+	script, err := os.ReadFile(filepath.Join(dir, "hooks", hook+".sh"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	declared := m.HookEvents[hook]
+	t := HookTest{Name: hook, Event: fx.Event, Matcher: declared.Matcher, ToolName: fx.Tool, Payload: fx.Payload}
+	if t.Event == "" {
+		t.Event = declared.Event
+	}
+	if t.Event == "" {
+		return nil, nil, errors.New("fixture sets no event and the hook declares none in hook_events")
+	}
+	if declared.Timeout > 0 {
+		t.Timeout = time.Duration(declared.Timeout) * time.Second
+	}
+	run, err := RunHook(ctx, script, t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var failures []string
+	want := 0
+	if fx.Expect.ExitCode != nil {
+		want = *fx.Expect.ExitCode
+	}
+	switch {
+	case run.TimedOut:
+		failures = append(failures, fmt.Sprintf("timed out after %s", run.Duration.Round(time.Millisecond)))
+	case run.ExitCode != want:
+		failures = append(failures, fmt.Sprintf("exit code %d, want %d%s", run.ExitCode, want, stderrHint(run.Stderr)))
+	}
+	if s := fx.Expect.StdoutContains; s != "" && !strings.Contains(run.Stdout, s) {
+		failures = append(failures, fmt.Sprintf("stdout does not contain %q", s))
+	}
+	if s := fx.Expect.StderrContains; s != "" && !strings.Contains(run.Stderr, s) {
+		failures = append(failures, fmt.Sprintf("stderr does not contain %q", s))
+	}
+	return run, failures, nil
+}
+
+// stderrHint quotes the first line of stderr for a failure message.
+func stderrHint(stderr string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(stderr), "\n")
+	if line == "" {
+		return ""
+	}
+	if len(line) > 120 {
+		line = line[:120] + "..."
+	}
+	return fmt.Sprintf(" (stderr: %s)", line)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package packs
+
+import "os/exec"
+
+// isolateProcess has no process groups to use on this platform; a timeout
+// kills the hook process only.
+func isolateProcess(cmd *exec.Cmd) {}
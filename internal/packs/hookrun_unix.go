@@ -0,0 +1,17 @@
+//go:build unix
+
+package packs
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolateProcess runs a hook in its own process group, so a timeout kills
+// anything it started as well.
+func isolateProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,183 @@
+package packs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PendingHookDir is the directory under .claude/hooks where Apply stages pack
+// hooks until they are approved. Staged hooks are not executable, and
+// ListInstalledHooks does not see them.
+const PendingHookDir = ".pending"
+
+// HookPath returns where an approved hook is installed.
+func HookPath(workspace, name string) string {
+	return filepath.Join(workspace, ".claude", "hooks", name+".sh")
+}
+
+// PendingHookPath returns where a hook waits for review.
+func PendingHookPath(workspace, name string) string {
+	return filepath.Join(workspace, ".claude", "hooks", PendingHookDir, name+".sh")
+}
+
+// ListPendingHooks returns the names of hooks staged for review.
+func ListPendingHooks(workspace string) []string {
+	entries, err := os.ReadDir(filepath.Join(workspace, ".claude", "hooks", PendingHookDir))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sh") {
+			names = append(names, strings.TrimSuffix(e.Name(), ".sh"))
+		}
+	}
+	return names
+}
+
+// ActivateHook moves a staged hook into .claude/hooks, replacing the
+// previously approved version, and makes it executable.
+func ActivateHook(workspace, name string) error {
+	dst := HookPath(workspace, name)
+	if err := os.Rename(PendingHookPath(workspace, name), dst); err != nil {
+		return fmt.Errorf("activate hook %s: %w", name, err)
+	}
+	return os.Chmod(dst, 0755)
+}
+
+// DiscardPendingHook removes a staged hook. The approved version, if any,
+// stays installed.
+func DiscardPendingHook(workspace, name string) error {
+	err := os.Remove(PendingHookPath(workspace, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// maxDiffLines bounds the quadratic line diff; larger files are reported as
+// replaced outright.
+const maxDiffLines = 2000
+
+// DiffLines returns a unified diff of two texts, or "" if they are equal.
+func DiffLines(oldName, newName, old, new string) string {
+	if old == new {
+		return ""
+	}
+	a, b := splitLines(old), splitLines(new)
+	ops := diffOps(a, b)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+	for start := 0; start < len(ops); {
+		// Find the next change and the run of ops it belongs to.
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		from := max(start-diffContext, 0)
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				break
+			}
+			end = run
+		}
+		to := min(end+diffContext, len(ops))
+
+		aLine, bLine, aCount, bCount := ops[from].a+1, ops[from].b+1, 0, 0
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+		for _, op := range ops[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", op.kind, op.text)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// diffOp is one line of an edit script: ' ' kept, '-' removed, '+' added.
+// a and b are the zero-based positions in the old and new text before it.
+type diffOp struct {
+	kind byte
+	text string
+	a, b int
+}
+
+// diffOps computes a line edit script from the longest common subsequence.
+func diffOps(a, b []string) []diffOp {
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		var ops []diffOp
+		for i, l := range a {
+			ops = append(ops, diffOp{'-', l, i, 0})
+		}
+		for j, l := range b {
+			ops = append(ops, diffOp{'+', l, len(a), j})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
@@ -0,0 +1,550 @@
+package packs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RiskLevel grades what a hook script can do to the machine it runs on.
+type RiskLevel int
+
+const (
+	RiskNone RiskLevel = iota
+	RiskLow
+	RiskMedium
+	RiskHigh
+	RiskCritical
+)
+
+var riskNames = []string{"none", "low", "medium", "high", "critical"}
+
+// riskScores weights each level when findings are summed into a score.
+var riskScores = []int{0, 1, 3, 7, 15}
+
+func (r RiskLevel) String() string {
+	return riskNames[r]
+}
+
+// Score is the weight of one finding at this level.
+func (r RiskLevel) Score() int {
+	return riskScores[r]
+}
+
+// ParseRiskLevel parses a level name such as "high".
+func ParseRiskLevel(s string) (RiskLevel, error) {
+	i := slices.Index(riskNames, strings.ToLower(strings.TrimSpace(s)))
+	if i < 0 {
+		return RiskNone, fmt.Errorf("unknown risk level %q (want one of %s)", s, strings.Join(riskNames, ", "))
+	}
+	return RiskLevel(i), nil
+}
+
+// HookFinding is one risky construct found in a hook script.
+type HookFinding struct {
+	Rule    string
+	Level   RiskLevel
+	Line    int
+	Message string
+}
+
+// HookScan is the result of scanning one hook script. Score sums the
+// weights of all findings; Level is the highest finding level.
+type HookScan struct {
+	Findings []HookFinding
+	Score    int
+	Level    RiskLevel
+}
+
+// Summary renders the level and score, e.g. "high (score 10, 2 findings)".
+func (s *HookScan) Summary() string {
+	if len(s.Findings) == 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%s (score %d, %d finding(s))", s.Level, s.Score, len(s.Findings))
+}
+
+// ScanHookFile scans the hook script at path.
+func ScanHookFile(path string) (*HookScan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ScanHook(data), nil
+}
+
+// ScanHooks scans the hooks of a fetched pack, keyed by hook name.
+func (f *FetchedPack) ScanHooks() (map[string]*HookScan, error) {
+	scans := make(map[string]*HookScan, len(f.Manifest.Contents.Hooks))
+	for _, name := range f.Manifest.Contents.Hooks {
+		scan, err := ScanHookFile(filepath.Join(f.Dir, "hooks", name+".sh"))
+		if err != nil {
+			return nil, fmt.Errorf("scan hook %s: %w", name, err)
+		}
+		scans[name] = scan
+	}
+	return scans, nil
+}
+
+var (
+	shells          = []string{"sh", "bash", "zsh", "dash", "ksh", "fish", "python", "python3", "perl", "ruby", "node"}
+	downloaders     = []string{"curl", "wget"}
+	networkCommands = []string{"curl", "wget", "nc", "ncat", "netcat", "socat", "ssh", "scp", "sftp", "ftp", "telnet", "rsync"}
+	privilegeTools  = []string{"sudo", "doas", "su", "pkexec"}
+	// commandWrappers run the command that follows them.
+	commandWrappers = []string{"command", "exec", "nohup", "time", "env", "xargs", "nice"}
+
+	credentialPath = regexp.MustCompile(`(?:~|\$HOME|\$\{HOME\})/\.(?:ssh|aws|gnupg|netrc|git-credentials|npmrc|pypirc|docker/config\.json|kube/config)|\bid_(?:rsa|ecdsa|ed25519)\b`)
+	secretVariable = regexp.MustCompile(`\$\{?[A-Za-z0-9_]*(?:TOKEN|SECRET|PASSWORD|PASSWD|API_KEY|ACCESS_KEY|PRIVATE_KEY)[A-Za-z0-9_]*\}?`)
+	shellProfile   = regexp.MustCompile(`\.(?:bashrc|bash_profile|zshrc|zprofile|profile)\b`)
+	assignment     = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+)
+
+// ScanHook flags risky constructs in a shell script: downloads piped into a
+// shell, eval of downloaded content, rm -rf on variables, credential and
+// secret reads, privilege escalation, network access, and writes to shell
+// profiles.
+func ScanHook(src []byte) *HookScan {
+	s := &scanner{seen: make(map[string]bool)}
+	s.scan(string(src), 0)
+	slices.SortStableFunc(s.result.Findings, func(a, b HookFinding) int { return a.Line - b.Line })
+	return &s.result
+}
+
+type scanner struct {
+	result HookScan
+	seen   map[string]bool
+}
+
+func (s *scanner) add(rule string, level RiskLevel, line int, format string, args ...any) {
+	key := fmt.Sprintf("%s:%d", rule, line)
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+	s.result.Findings = append(s.result.Findings, HookFinding{Rule: rule, Level: level, Line: line, Message: fmt.Sprintf(format, args...)})
+	s.result.Score += level.Score()
+	s.result.Level = max(s.result.Level, level)
+}
+
+// scan checks every pipeline of a script. Command substitutions are scanned
+// as scripts of their own, starting at the line they appear on.
+func (s *scanner) scan(src string, lineOffset int) {
+	for _, p := range parseShell(src) {
+		for _, c := range p {
+			c.line += lineOffset
+			for _, sub := range c.substs {
+				s.scan(sub, c.line-1)
+			}
+		}
+		s.checkPipeline(p)
+	}
+}
+
+func (s *scanner) checkPipeline(p []*shellCommand) {
+	fetchAt, network, secrets := -1, false, false
+	for i, c := range p {
+		name, args := c.name()
+		if slices.Contains(downloaders, name) && fetchAt < 0 {
+			fetchAt = i
+		}
+		if slices.Contains(networkCommands, name) {
+			network = true
+			s.add("network", RiskMedium, c.line, "`%s` accesses the network", name)
+		}
+		if fetchAt >= 0 && i > fetchAt && slices.Contains(shells, name) {
+			s.add("pipe-to-shell", RiskCritical, c.line, "output of `%s` is piped into `%s`", p[fetchAt].words[0], name)
+		}
+		if c.privileged() {
+			s.add("sudo", RiskHigh, c.line, "runs a command with elevated privileges")
+		}
+		s.checkCommand(c, name, args)
+
+		text := strings.Join(append(slices.Clone(c.words), c.substs...), " ")
+		if m := credentialPath.FindString(text); m != "" {
+			secrets = true
+			s.add("credentials", RiskHigh, c.line, "reads credentials at `%s`", m)
+		}
+		if m := secretVariable.FindString(text); m != "" {
+			secrets = true
+			s.add("env-secret", RiskMedium, c.line, "uses secret variable `%s`", m)
+		}
+		if network && secrets {
+			s.add("exfiltration", RiskHigh, c.line, "sends credentials or secrets over the network")
+		}
+	}
+}
+
+func (s *scanner) checkCommand(c *shellCommand, name string, args []string) {
+	downloads := slices.ContainsFunc(c.substs, func(sub string) bool {
+		return slices.ContainsFunc(downloaders, func(d string) bool { return containsWord(sub, d) })
+	})
+	switch {
+	case name == "eval" || name == "source" || name == ".":
+		if downloads {
+			s.add("eval-download", RiskCritical, c.line, "`%s` runs downloaded content", name)
+		} else if name == "eval" {
+			s.add("eval", RiskMedium, c.line, "`eval` runs dynamically built code")
+		}
+	case slices.Contains(shells, name):
+		if downloads {
+			s.add("eval-download", RiskCritical, c.line, "`%s` runs downloaded content", name)
+		}
+	case name == "rm":
+		s.checkRemove(c, args)
+	case name == "find" && slices.Contains(args, "-delete"):
+		s.add("delete", RiskMedium, c.line, "`find -delete` removes files")
+	case name == "printenv" || (name == "env" && len(args) == 0) || (name == "set" && len(args) == 0):
+		s.add("env-secret", RiskMedium, c.line, "`%s` dumps the environment, including secrets", name)
+	case name == "crontab":
+		s.add("persistence", RiskMedium, c.line, "`crontab` schedules commands to run later")
+	}
+
+	for i, w := range c.words {
+		if (w == ">" || w == ">>" || name == "tee") && i+1 < len(c.words) && shellProfile.MatchString(c.words[i+1]) {
+			s.add("persistence", RiskMedium, c.line, "writes to shell profile `%s`", c.words[i+1])
+		}
+	}
+}
+
+func (s *scanner) checkRemove(c *shellCommand, args []string) {
+	recursive, force := false, false
+	var targets []string
+	for _, a := range args {
+		switch {
+		case a == "--recursive":
+			recursive = true
+		case a == "--force":
+			force = true
+		case strings.HasPrefix(a, "-") && !strings.HasPrefix(a, "--"):
+			recursive = recursive || strings.ContainsAny(a, "rR")
+			force = force || strings.Contains(a, "f")
+		default:
+			targets = append(targets, a)
+		}
+	}
+	if !recursive {
+		return
+	}
+	for _, t := range targets {
+		switch {
+		case t == "/" || t == "/*" || t == "~" || t == "~/" || t == "$HOME" || t == "${HOME}":
+			s.add("rm-rf", RiskCritical, c.line, "recursively deletes `%s`", t)
+			return
+		case strings.Contains(t, "$") && force:
+			s.add("rm-rf", RiskHigh, c.line, "`rm -rf` on `%s` deletes the wrong tree if the variable is empty or unexpected", t)
+			return
+		}
+	}
+	s.add("delete", RiskLow, c.line, "recursively deletes files")
+}
+
+// containsWord reports whether text mentions cmd as a separate word.
+func containsWord(text, cmd string) bool {
+	return slices.Contains(strings.FieldsFunc(text, func(r rune) bool {
+		return !(r == '-' || r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}), cmd)
+}
+
+// shellCommand is one simple command of a pipeline. Words have quotes
+// removed; command substitutions are kept in place and also collected in
+// substs, without their $( ) or backquotes, for separate scanning.
+type shellCommand struct {
+	line   int
+	words  []string
+	substs []string
+}
+
+// name returns the command that runs, skipping variable assignments and
+// wrappers such as sudo or env, and its arguments.
+func (c *shellCommand) name() (string, []string) {
+	words := c.words
+	for len(words) > 0 {
+		w := words[0]
+		switch {
+		case assignment.MatchString(w):
+			words = words[1:]
+		case slices.Contains(privilegeTools, w) || slices.Contains(commandWrappers, w) && len(words) > 1:
+			words = words[1:]
+			for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+				words = words[1:]
+			}
+		default:
+			return filepath.Base(w), words[1:]
+		}
+	}
+	return "", nil
+}
+
+func (c *shellCommand) privileged() bool {
+	for _, w := range c.words {
+		if assignment.MatchString(w) {
+			continue
+		}
+		return slices.Contains(privilegeTools, w)
+	}
+	return false
+}
+
+// parseShell splits a script into pipelines of simple commands. It handles
+// quoting, escapes, comments, line continuations, command and process
+// substitution, redirections, and here-documents, which is enough to find
+// commands reliably without executing anything.
+func parseShell(src string) [][]*shellCommand {
+	p := &shellParser{src: src, line: 1}
+	p.run()
+	return p.pipelines
+}
+
+type shellParser struct {
+	src       string
+	pos       int
+	line      int
+	pipelines [][]*shellCommand
+	pipeline  []*shellCommand
+	cmd       *shellCommand
+	word      strings.Builder
+	inWord    bool
+	heredocs  []string
+}
+
+func (p *shellParser) run() {
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		switch {
+		case ch == '\\' && p.pos+1 < len(p.src):
+			if p.src[p.pos+1] == '\n' {
+				p.line++
+			} else {
+				p.appendWord(p.src[p.pos+1 : p.pos+2])
+			}
+			p.pos += 2
+		case ch == '#' && !p.inWord:
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case ch == '\n':
+			p.endPipeline()
+			p.pos++
+			p.line++
+			p.skipHeredocs()
+		case ch == ' ' || ch == '\t' || ch == '\r':
+			p.endWord()
+			p.pos++
+		case ch == '\'':
+			end := strings.IndexByte(p.src[p.pos+1:], '\'')
+			if end < 0 {
+				end = len(p.src) - p.pos - 1
+			}
+			text := p.src[p.pos+1 : p.pos+1+end]
+			p.appendWord(text)
+			p.line += strings.Count(text, "\n")
+			p.pos += end + 2
+		case ch == '"':
+			p.doubleQuoted()
+		case ch == '`':
+			end := strings.IndexByte(p.src[p.pos+1:], '`')
+			if end < 0 {
+				end = len(p.src) - p.pos - 1
+			}
+			p.substitution(p.src[p.pos+1:p.pos+1+end], p.src[p.pos:min(p.pos+end+2, len(p.src))])
+			p.pos += end + 2
+		case ch == '$' && strings.HasPrefix(p.src[p.pos:], "$(("):
+			p.appendWord(p.balanced(p.pos + 1))
+		case ch == '$' && strings.HasPrefix(p.src[p.pos:], "$("):
+			raw := p.balanced(p.pos + 1)
+			p.substitution(unwrap(raw), "$"+raw)
+		case (ch == '<' || ch == '>') && strings.HasPrefix(p.src[p.pos+1:], "("):
+			raw := p.balanced(p.pos + 1)
+			p.substitution(unwrap(raw), string(ch)+raw)
+		case ch == '<' && strings.HasPrefix(p.src[p.pos:], "<<"):
+			p.endWord()
+			p.pos += 2
+			if p.pos < len(p.src) && p.src[p.pos] == '<' {
+				p.pos++ // here-string
+				p.addWord("<<<")
+				continue
+			}
+			p.addWord("<<")
+			p.heredoc()
+		case ch == '>' || ch == '<':
+			p.endWord()
+			op := string(ch)
+			p.pos++
+			for p.pos < len(p.src) && (p.src[p.pos] == '>' || p.src[p.pos] == '&' || p.src[p.pos] == '|') {
+				op += p.src[p.pos : p.pos+1]
+				p.pos++
+			}
+			p.addWord(op)
+		case ch == '|' && !strings.HasPrefix(p.src[p.pos:], "||"):
+			p.endCommand()
+			p.pos++
+			if p.pos < len(p.src) && p.src[p.pos] == '&' {
+				p.pos++
+			}
+		case ch == ';' || ch == '&' || ch == '|' || ch == '(' || ch == ')' || ch == '{' && !p.inWord || ch == '}' && !p.inWord:
+			p.endPipeline()
+			p.pos++
+		default:
+			p.appendWord(p.src[p.pos : p.pos+1])
+			p.pos++
+		}
+	}
+	p.endPipeline()
+}
+
+// doubleQuoted consumes a "..." string, keeping substitutions inside it.
+func (p *shellParser) doubleQuoted() {
+	p.pos++
+	p.inWord = true
+	for p.pos < len(p.src) && p.src[p.pos] != '"' {
+		switch ch := p.src[p.pos]; {
+		case ch == '\\' && p.pos+1 < len(p.src):
+			p.appendWord(p.src[p.pos+1 : p.pos+2])
+			p.pos += 2
+		case strings.HasPrefix(p.src[p.pos:], "$(") && !strings.HasPrefix(p.src[p.pos:], "$(("):
+			raw := p.balanced(p.pos + 1)
+			p.substitution(unwrap(raw), "$"+raw)
+		case ch == '`':
+			end := strings.IndexByte(p.src[p.pos+1:], '`')
+			if end < 0 {
+				end = len(p.src) - p.pos - 1
+			}
+			p.substitution(p.src[p.pos+1:p.pos+1+end], p.src[p.pos:min(p.pos+end+2, len(p.src))])
+			p.pos += end + 2
+		default:
+			if ch == '\n' {
+				p.line++
+			}
+			p.appendWord(p.src[p.pos : p.pos+1])
+			p.pos++
+		}
+	}
+	p.pos++
+}
+
+// balanced returns the parenthesised text starting at start, including the
+// parentheses, and moves past it. Quotes inside are respected.
+func (p *shellParser) balanced(start int) string {
+	depth, i := 0, start
+	var quote byte
+	for ; i < len(p.src); i++ {
+		ch := p.src[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			} else if ch == '\\' && quote == '"' {
+				i++
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '\\':
+			i++
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	end := min(i+1, len(p.src))
+	p.pos = end
+	return p.src[start:end]
+}
+
+// unwrap strips the parentheses balanced returned; an unterminated
+// substitution has no closing one.
+func unwrap(raw string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(raw, "("), ")")
+}
+
+func (p *shellParser) substitution(inner, raw string) {
+	p.command().substs = append(p.command().substs, inner)
+	p.appendWord(raw)
+	p.line += strings.Count(raw, "\n")
+}
+
+// heredoc records the delimiter of a here-document so its body is skipped
+// after the current line.
+func (p *shellParser) heredoc() {
+	if p.pos < len(p.src) && p.src[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune(" \t\n;|&<>()", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	delim := strings.Trim(p.src[start:p.pos], `'"`)
+	p.addWord(delim)
+	if delim != "" {
+		p.heredocs = append(p.heredocs, delim)
+	}
+}
+
+func (p *shellParser) skipHeredocs() {
+	for _, delim := range p.heredocs {
+		for p.pos < len(p.src) {
+			end := strings.IndexByte(p.src[p.pos:], '\n')
+			if end < 0 {
+				end = len(p.src) - p.pos
+			}
+			line := p.src[p.pos : p.pos+end]
+			p.pos = min(p.pos+end+1, len(p.src))
+			p.line++
+			if strings.TrimLeft(line, "\t") == delim {
+				break
+			}
+		}
+	}
+	p.heredocs = nil
+}
+
+func (p *shellParser) command() *shellCommand {
+	if p.cmd == nil {
+		p.cmd = &shellCommand{line: p.line}
+	}
+	return p.cmd
+}
+
+func (p *shellParser) appendWord(s string) {
+	p.command()
+	p.word.WriteString(s)
+	p.inWord = true
+}
+
+func (p *shellParser) addWord(w string) {
+	p.command().words = append(p.cmd.words, w)
+}
+
+func (p *shellParser) endWord() {
+	if p.inWord {
+		p.addWord(p.word.String())
+		p.word.Reset()
+		p.inWord = false
+	}
+}
+
+func (p *shellParser) endCommand() {
+	p.endWord()
+	if p.cmd != nil && (len(p.cmd.words) > 0 || len(p.cmd.substs) > 0) {
+		p.pipeline = append(p.pipeline, p.cmd)
+	}
+	p.cmd = nil
+}
+
+func (p *shellParser) endPipeline() {
+	p.endCommand()
+	if len(p.pipeline) > 0 {
+		p.pipelines = append(p.pipelines, p.pipeline)
+	}
+	p.pipeline = nil
+}
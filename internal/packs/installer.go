@@ -1,9 +1,10 @@
 package packs
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,19 +13,29 @@ import (
 
 // PackManifest is the parsed pack.json from a pack repo.
 type PackManifest struct {
-	Name        string   `json:"name"`
-	Description string   `json:"description"`
-	Version     string   `json:"version"`
-	Type        string   `json:"type"`
-	License     string   `json:"license"`
-	Stacks      []string `json:"stacks"`
-	Contents    struct {
-		Skills    []string `json:"skills"`
-		Agents    []string `json:"agents"`
-		Hooks     []string `json:"hooks"`
-		Workflows []string `json:"workflows"`
-	} `json:"contents"`
-	Tags []string `json:"tags"`
+	Schema      string       `json:"$schema,omitempty"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Version     string       `json:"version"`
+	Type        string       `json:"type"`
+	License     string       `json:"license"`
+	Stacks      []string     `json:"stacks"`
+	Requires    []string     `json:"requires"`
+	Contents    PackContents `json:"contents"`
+	Tags        []string     `json:"tags"`
+	Changelog   string       `json:"changelog,omitempty"`
+	// HookEvents maps hook names to the event each is wired to once approved.
+	HookEvents map[string]HookEvent `json:"hook_events,omitempty"`
+	// Digests maps pack-relative content paths to "sha256:<hex>".
+	Digests map[string]string `json:"digests,omitempty"`
+}
+
+// PackContents lists the content a pack provides, by name.
+type PackContents struct {
+	Skills    []string `json:"skills"`
+	Agents    []string `json:"agents"`
+	Hooks     []string `json:"hooks"`
+	Workflows []string `json:"workflows"`
 }
 
 // ResolvePackRepo resolves a short name, org/repo, or full github.com/org/repo
@@ -63,8 +74,20 @@ func ResolvePackRepo(input string) string {
 	return "github.com/orchestra-mcp/pack-" + input
 }
 
-// InstallPack clones a pack repo and copies its contents to the workspace.
-func InstallPack(workspace, repo, version string) (*PackManifest, error) {
+// FetchedPack is a pack repo cloned into a temporary directory, ready to be
+// applied to a workspace. Call Cleanup once it is no longer needed.
+type FetchedPack struct {
+	Repo     string
+	Dir      string
+	Commit   string
+	Manifest *PackManifest
+}
+
+// FetchPack clones a pack repo at the given version (latest if empty) into a
+// temporary directory and validates its pack.json. An invalid manifest is
+// reported as a *ManifestError, and content that fails CheckPackFiles as an
+// *UnsafePackError.
+func FetchPack(ctx context.Context, repo, version string) (*FetchedPack, error) {
 	if _, err := exec.LookPath("git"); err != nil {
 		return nil, fmt.Errorf("git not found in PATH")
 	}
@@ -73,95 +96,192 @@ func InstallPack(workspace, repo, version string) (*PackManifest, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create temp dir: %w", err)
 	}
-	defer os.RemoveAll(tmpDir)
+	fetched := &FetchedPack{Repo: repo, Dir: tmpDir}
 
 	// Clone the repo.
-	cloneURL := "https://" + repo + ".git"
+	url := cloneURL(repo)
 	cloneArgs := []string{"clone", "--depth", "1"}
 	if version != "" {
 		cloneArgs = append(cloneArgs, "--branch", version)
 	}
-	cloneArgs = append(cloneArgs, cloneURL, tmpDir)
+	cloneArgs = append(cloneArgs, url, tmpDir)
 
-	cmd := exec.Command("git", cloneArgs...)
+	cmd := exec.CommandContext(ctx, "git", cloneArgs...)
 	cmd.Stderr = io.Discard
 	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("git clone %s: %w", cloneURL, err)
+		fetched.Cleanup()
+		return nil, fmt.Errorf("git clone %s: %w", url, err)
 	}
 
-	// Read and parse pack.json.
-	packJSON, err := os.ReadFile(filepath.Join(tmpDir, "pack.json"))
+	// Validate pack.json against the repo before anything is copied.
+	manifest, err := ReadManifest(tmpDir)
 	if err != nil {
-		return nil, fmt.Errorf("read pack.json: %w (is this a valid pack repo?)", err)
+		fetched.Cleanup()
+		return nil, err
+	}
+	fetched.Manifest = manifest
+	if unsafe := CheckPackFiles(tmpDir, manifest); len(unsafe) > 0 {
+		fetched.Cleanup()
+		return nil, &UnsafePackError{Repo: repo, Files: unsafe}
 	}
 
-	var manifest PackManifest
-	if err := json.Unmarshal(packJSON, &manifest); err != nil {
-		return nil, fmt.Errorf("parse pack.json: %w", err)
+	if out, err := exec.CommandContext(ctx, "git", "-C", tmpDir, "rev-parse", "HEAD").Output(); err == nil {
+		fetched.Commit = strings.TrimSpace(string(out))
 	}
+	return fetched, nil
+}
 
+// cloneURL returns the git URL for a repo path. Local directories and file://
+// URLs are used as-is so packs can be installed from a checkout.
+func cloneURL(repo string) string {
+	if filepath.IsAbs(repo) || strings.HasPrefix(repo, "file://") {
+		return repo
+	}
+	return "https://" + repo + ".git"
+}
+
+// Cleanup removes the temporary clone.
+func (f *FetchedPack) Cleanup() {
+	os.RemoveAll(f.Dir)
+}
+
+// Apply copies the pack's contents into the workspace's .claude directory,
+// overwriting any previous copies of the same content. Hooks are staged in
+// the pending directory rather than installed; see ActivateHook.
+//
+// Every destination must resolve inside .claude; see confinedPath.
+func (f *FetchedPack) Apply(workspace string) error {
+	manifest := f.Manifest
 	claudeDir := filepath.Join(workspace, ".claude")
+	if err := os.MkdirAll(claudeDir, 0755); err != nil {
+		return err
+	}
 
 	// Copy skills.
 	for _, name := range manifest.Contents.Skills {
-		src := filepath.Join(tmpDir, "skills", name)
-		dst := filepath.Join(claudeDir, "skills", name)
+		src := filepath.Join(f.Dir, "skills", name)
+		dst, err := confinedPath(claudeDir, "skills", name)
+		if err != nil {
+			return fmt.Errorf("copy skill %s: %w", name, err)
+		}
 		if err := copyDir(src, dst); err != nil {
-			return nil, fmt.Errorf("copy skill %s: %w", name, err)
+			return fmt.Errorf("copy skill %s: %w", name, err)
 		}
 	}
 
 	// Copy agents.
 	for _, name := range manifest.Contents.Agents {
-		src := filepath.Join(tmpDir, "agents", name+".md")
-		dst := filepath.Join(claudeDir, "agents", name+".md")
+		src := filepath.Join(f.Dir, "agents", name+".md")
+		dst, err := confinedPath(claudeDir, "agents", name+".md")
+		if err != nil {
+			return fmt.Errorf("copy agent %s: %w", name, err)
+		}
 		if err := copyFile(src, dst); err != nil {
-			return nil, fmt.Errorf("copy agent %s: %w", name, err)
+			return fmt.Errorf("copy agent %s: %w", name, err)
 		}
 	}
 
-	// Copy hooks.
+	// Stage hooks for review.
 	for _, name := range manifest.Contents.Hooks {
-		src := filepath.Join(tmpDir, "hooks", name+".sh")
-		dst := filepath.Join(claudeDir, "hooks", name+".sh")
+		src := filepath.Join(f.Dir, "hooks", name+".sh")
+		dst, err := confinedPath(claudeDir, "hooks", PendingHookDir, name+".sh")
+		if err != nil {
+			return fmt.Errorf("copy hook %s: %w", name, err)
+		}
 		if err := copyFile(src, dst); err != nil {
-			return nil, fmt.Errorf("copy hook %s: %w", name, err)
+			return fmt.Errorf("copy hook %s: %w", name, err)
 		}
-		os.Chmod(dst, 0755)
+		os.Chmod(dst, 0644)
 	}
 
 	// Copy workflows.
 	for _, name := range manifest.Contents.Workflows {
-		src := filepath.Join(tmpDir, "workflow", name)
-		dst := filepath.Join(claudeDir, "workflows", name)
+		src := filepath.Join(f.Dir, "workflow", name)
+		dst, err := confinedPath(claudeDir, "workflows", name)
+		if err != nil {
+			return fmt.Errorf("copy workflow %s: %w", name, err)
+		}
 		if err := copyFile(src, dst); err != nil {
-			return nil, fmt.Errorf("copy workflow %s: %w", name, err)
+			return fmt.Errorf("copy workflow %s: %w", name, err)
 		}
 	}
 
-	return &manifest, nil
+	return nil
 }
 
-// RemovePack removes installed files for a pack.
+// InstallPack clones a pack repo and copies its contents to the workspace.
+func InstallPack(workspace, repo, version string) (*PackManifest, error) {
+	fetched, err := FetchPack(context.Background(), repo, version)
+	if err != nil {
+		return nil, err
+	}
+	defer fetched.Cleanup()
+
+	if err := fetched.Apply(workspace); err != nil {
+		return nil, err
+	}
+	return fetched.Manifest, nil
+}
+
+// RemovePack removes installed files for a pack and unwires its hooks from
+// settings.json. Names that would resolve outside .claude, such as from a
+// hand-edited registry, are skipped.
 func RemovePack(workspace string, skills, agents, hooks, workflows []string) error {
 	claudeDir := filepath.Join(workspace, ".claude")
+	remove := func(removeFn func(string) error, elem ...string) {
+		if path, err := confinedPath(claudeDir, elem...); err == nil {
+			removeFn(path)
+		}
+	}
 
 	for _, name := range skills {
-		os.RemoveAll(filepath.Join(claudeDir, "skills", name))
+		remove(os.RemoveAll, "skills", name)
 	}
 	for _, name := range agents {
-		os.Remove(filepath.Join(claudeDir, "agents", name+".md"))
+		remove(os.Remove, "agents", name+".md")
 	}
+	var unwireErr error
 	for _, name := range hooks {
-		os.Remove(filepath.Join(claudeDir, "hooks", name+".sh"))
+		remove(os.Remove, "hooks", name+".sh")
+		remove(os.Remove, "hooks", PendingHookDir, name+".sh")
+		if err := UnwireHook(workspace, name); err != nil && unwireErr == nil {
+			unwireErr = err
+		}
 	}
 	for _, name := range workflows {
-		os.Remove(filepath.Join(claudeDir, "workflows", name))
+		remove(os.Remove, "workflows", name)
 	}
-	return nil
+	return unwireErr
+}
+
+// RemoveReplaced removes content from a previous install that the updated
+// pack no longer provides. Content present in both is left for Apply to
+// overwrite, so a failed update never leaves the workspace without it.
+func RemoveReplaced(workspace string, old, updated PackContents) error {
+	return RemovePack(workspace,
+		missingFrom(old.Skills, updated.Skills),
+		missingFrom(old.Agents, updated.Agents),
+		missingFrom(old.Hooks, updated.Hooks),
+		missingFrom(old.Workflows, updated.Workflows))
 }
 
-// copyDir copies a directory recursively.
+// missingFrom returns the names in old that are not in updated.
+func missingFrom(old, updated []string) []string {
+	keep := make(map[string]bool, len(updated))
+	for _, name := range updated {
+		keep[name] = true
+	}
+	var gone []string
+	for _, name := range old {
+		if !keep[name] {
+			gone = append(gone, name)
+		}
+	}
+	return gone
+}
+
+// copyDir copies a directory recursively. Symlinks are copied as links;
+// CheckPackFiles has already confined them to the directory.
 func copyDir(src, dst string) error {
 	if err := os.MkdirAll(dst, 0755); err != nil {
 		return err
@@ -173,11 +293,23 @@ func copyDir(src, dst string) error {
 	for _, entry := range entries {
 		srcPath := filepath.Join(src, entry.Name())
 		dstPath := filepath.Join(dst, entry.Name())
-		if entry.IsDir() {
+		switch {
+		case entry.IsDir():
 			if err := copyDir(srcPath, dstPath); err != nil {
 				return err
 			}
-		} else {
+		case entry.Type()&fs.ModeSymlink != 0:
+			target, err := os.Readlink(srcPath)
+			if err != nil {
+				return err
+			}
+			if err := removeLink(dstPath); err != nil {
+				return err
+			}
+			if err := os.Symlink(target, dstPath); err != nil {
+				return err
+			}
+		default:
 			if err := copyFile(srcPath, dstPath); err != nil {
 				return err
 			}
@@ -186,8 +318,14 @@ func copyDir(src, dst string) error {
 	return nil
 }
 
-// copyFile copies a single file, creating parent directories.
+// copyFile copies a single regular file, creating parent directories. A
+// symlink at dst is replaced rather than written through.
 func copyFile(src, dst string) error {
+	if info, err := os.Lstat(src); err != nil {
+		return err
+	} else if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", src)
+	}
 	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return err
 	}
@@ -195,9 +333,20 @@ func copyFile(src, dst string) error {
 	if err != nil {
 		return err
 	}
+	if err := removeLink(dst); err != nil {
+		return err
+	}
 	return os.WriteFile(dst, data, 0644)
 }
 
+// removeLink removes path if it is a symlink.
+func removeLink(path string) error {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+		return os.Remove(path)
+	}
+	return nil
+}
+
 // ListInstalledSkills scans the workspace for installed skills.
 func ListInstalledSkills(workspace string) []string {
 	dir := filepath.Join(workspace, ".claude", "skills")
@@ -275,6 +424,8 @@ type ClaudeSkillInfo struct {
 	Name        string
 	Description string
 	Content     string
+	// Frontmatter is nil when the file has none or it is not valid YAML.
+	Frontmatter *Frontmatter
 }
 
 // ClaudeAgentInfo holds parsed metadata from a .claude/agents/*.md file.
@@ -283,6 +434,8 @@ type ClaudeAgentInfo struct {
 	Name        string
 	Description string
 	Content     string
+	// Frontmatter is nil when the file has none or it is not valid YAML.
+	Frontmatter *Frontmatter
 }
 
 // ScanClaudeSkills scans .claude/skills/ and returns all parseable skills.
@@ -304,12 +457,13 @@ func ScanClaudeSkills(workspace string) []ClaudeSkillInfo {
 			continue
 		}
 		content := string(data)
-		name, desc := parseFrontmatter(content, slug)
+		fm, name, desc := describeContent(content, slug)
 		result = append(result, ClaudeSkillInfo{
 			Slug:        slug,
 			Name:        name,
 			Description: desc,
 			Content:     content,
+			Frontmatter: fm,
 		})
 	}
 	return result
@@ -333,12 +487,13 @@ func ScanClaudeAgents(workspace string) []ClaudeAgentInfo {
 			continue
 		}
 		content := string(data)
-		name, desc := parseFrontmatter(content, slug)
+		fm, name, desc := describeContent(content, slug)
 		result = append(result, ClaudeAgentInfo{
 			Slug:        slug,
 			Name:        name,
 			Description: desc,
 			Content:     content,
+			Frontmatter: fm,
 		})
 	}
 	return result
@@ -347,71 +502,22 @@ func ScanClaudeAgents(workspace string) []ClaudeAgentInfo {
 // StripFrontmatter removes YAML frontmatter (---...---) from content,
 // returning only the markdown body that follows it.
 func StripFrontmatter(content string) string {
-	if !strings.HasPrefix(content, "---") {
-		return content
-	}
-	// Find the closing ---
-	rest := content[3:]
-	// Skip optional \r\n or \n after opening ---
-	if strings.HasPrefix(rest, "\r\n") {
-		rest = rest[2:]
-	} else if strings.HasPrefix(rest, "\n") {
-		rest = rest[1:]
-	}
-	idx := strings.Index(rest, "\n---")
-	if idx < 0 {
-		return content
-	}
-	body := rest[idx+4:] // skip \n---
-	// Skip optional \r\n or \n after closing ---
-	if strings.HasPrefix(body, "\r\n") {
-		body = body[2:]
-	} else if strings.HasPrefix(body, "\n") {
-		body = body[1:]
-	}
-	// Skip one more blank line if present
-	if strings.HasPrefix(body, "\r\n") {
-		body = body[2:]
-	} else if strings.HasPrefix(body, "\n") {
-		body = body[1:]
-	}
+	_, body, _ := ParseFrontmatter(content)
 	return body
 }
 
-// parseFrontmatter extracts name and description from YAML frontmatter.
-// Falls back to titleCase(slug) and empty string if not found.
-func parseFrontmatter(content, slug string) (name, description string) {
+// describeContent parses a skill or agent file's frontmatter for its name and
+// description. Falls back to titleCase(slug) and empty string if not found.
+func describeContent(content, slug string) (fm *Frontmatter, name, description string) {
 	name = toTitleCase(slug)
-	lines := strings.Split(content, "\n")
-	inFrontmatter := false
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "---" {
-			if !inFrontmatter {
-				inFrontmatter = true
-				continue
-			}
-			break
-		}
-		if !inFrontmatter {
-			continue
-		}
-		if strings.HasPrefix(line, "name:") {
-			v := strings.TrimSpace(strings.TrimPrefix(line, "name:"))
-			v = strings.Trim(v, `"'`)
-			if v != "" {
-				name = v
-			}
-		}
-		if strings.HasPrefix(line, "description:") {
-			v := strings.TrimSpace(strings.TrimPrefix(line, "description:"))
-			v = strings.Trim(v, `"'>-`)
-			if v != "" {
-				description = v
-			}
-		}
+	fm, _, err := ParseFrontmatter(content)
+	if err != nil || fm == nil {
+		return nil, name, ""
+	}
+	if fm.Name != "" {
+		name = fm.Name
 	}
-	return name, description
+	return fm, name, fm.Description
 }
 
 // toTitleCase converts a slug like "my-skill" to "My Skill".
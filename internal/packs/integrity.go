@@ -0,0 +1,286 @@
+package packs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// digestPattern matches a pack.json digest value.
+var digestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// IntegrityError reports why a fetched pack failed verification.
+type IntegrityError struct {
+	Repo     string
+	Problems []string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("integrity check failed for %s: %s", e.Repo, strings.Join(e.Problems, "; "))
+}
+
+// Verification describes how a fetched pack was verified.
+type Verification struct {
+	Signer  string // ID of the trusted key that signed pack.json, if any
+	Digests int    // number of content files checked against pack.json digests
+	Note    string // why the pack is only partly verified, if it is
+}
+
+// Summary renders the verification for tool output.
+func (v *Verification) Summary() string {
+	var parts []string
+	if v.Signer != "" {
+		parts = append(parts, "signed by "+v.Signer)
+	}
+	if v.Digests > 0 {
+		parts = append(parts, fmt.Sprintf("%d file digest(s) verified", v.Digests))
+	}
+	if len(parts) == 0 {
+		parts = append(parts, "unverified")
+	}
+	s := strings.Join(parts, ", ")
+	if v.Note != "" {
+		s += " (" + v.Note + ")"
+	}
+	return s
+}
+
+// Verify checks the fetched pack before it is applied. When trusted keys are
+// configured for the repo, pack.json must carry a signature from one of
+// them and declare digests. Declared digests must cover every file Apply
+// would install and match it exactly. A mismatch is an *IntegrityError.
+func (f *FetchedPack) Verify(trusted []string) (*Verification, error) {
+	v := &Verification{}
+	bad := &IntegrityError{Repo: f.Repo}
+	manifest, err := os.ReadFile(filepath.Join(f.Dir, "pack.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	signature, sigErr := os.ReadFile(filepath.Join(f.Dir, SignatureFile))
+	switch {
+	case len(trusted) > 0 && sigErr != nil:
+		bad.Problems = append(bad.Problems, fmt.Sprintf("trusted keys are configured for this repo but %s is missing", SignatureFile))
+	case len(trusted) > 0:
+		signer, err := VerifySignature(manifest, signature, trusted)
+		if err != nil {
+			bad.Problems = append(bad.Problems, err.Error())
+		}
+		v.Signer = signer
+		if len(f.Manifest.Digests) == 0 {
+			bad.Problems = append(bad.Problems, "signed packs must declare digests so the signature covers their content")
+		}
+	case sigErr == nil:
+		v.Note = "signature not checked: no trusted keys configured for this repo"
+	}
+
+	if len(f.Manifest.Digests) > 0 {
+		actual, err := ComputeDigests(f.Dir, f.Manifest.Contents)
+		if err != nil {
+			return nil, err
+		}
+		bad.Problems = append(bad.Problems, compareDigests(f.Manifest.Digests, actual, "declared in pack.json")...)
+		v.Digests = len(actual)
+	} else if v.Note == "" {
+		v.Note = "pack.json declares no digests"
+	}
+
+	if len(bad.Problems) > 0 {
+		return nil, bad
+	}
+	return v, nil
+}
+
+// InstalledDigests returns the digests of the files the pack installs,
+// keyed by path relative to the workspace's .claude directory, for
+// recording in the registry.
+func (f *FetchedPack) InstalledDigests() (map[string]string, error) {
+	digests, err := ComputeDigests(f.Dir, f.Manifest.Contents)
+	if err != nil {
+		return nil, err
+	}
+	installed := make(map[string]string, len(digests))
+	for rel, d := range digests {
+		installed[InstalledPath(rel)] = d
+	}
+	return installed, nil
+}
+
+// InstalledPath maps a pack-relative content path to where Apply installs it
+// under .claude: everything keeps its path except workflow/, which is
+// installed into workflows/.
+func InstalledPath(rel string) string {
+	if rest, ok := strings.CutPrefix(rel, "workflow/"); ok {
+		return "workflows/" + rest
+	}
+	return rel
+}
+
+// ContentFiles lists the pack-relative, slash-separated paths of every file
+// Apply copies for the given contents, sorted.
+func ContentFiles(dir string, c PackContents) ([]string, error) {
+	var files []string
+	for _, name := range c.Skills {
+		root := filepath.Join(dir, "skills", name)
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			files = append(files, filepath.ToSlash(rel))
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range c.Agents {
+		files = append(files, "agents/"+name+".md")
+	}
+	for _, name := range c.Hooks {
+		files = append(files, "hooks/"+name+".sh")
+	}
+	for _, name := range c.Workflows {
+		files = append(files, "workflow/"+name)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// ComputeDigests hashes every content file of a pack directory.
+func ComputeDigests(dir string, c PackContents) (map[string]string, error) {
+	files, err := ContentFiles(dir, c)
+	if err != nil {
+		return nil, err
+	}
+	digests := make(map[string]string, len(files))
+	for _, rel := range files {
+		d, err := FileDigest(filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, err
+		}
+		digests[rel] = d
+	}
+	return digests, nil
+}
+
+// FileDigest returns "sha256:<hex>" for a file.
+func FileDigest(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// compareDigests reports files whose digests differ, expected files that are
+// missing, and actual files that were not expected.
+func compareDigests(expected, actual map[string]string, source string) []string {
+	var problems []string
+	for _, rel := range slices.Sorted(maps.Keys(expected)) {
+		got, ok := actual[rel]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("%s is %s but not part of the pack contents", rel, source))
+		case got != expected[rel]:
+			problems = append(problems, fmt.Sprintf("%s does not match its digest", rel))
+		}
+	}
+	for _, rel := range slices.Sorted(maps.Keys(actual)) {
+		if _, ok := expected[rel]; !ok {
+			problems = append(problems, fmt.Sprintf("%s has no digest %s", rel, source))
+		}
+	}
+	return problems
+}
+
+// FileStatus values reported by VerifyInstalled.
+const (
+	FileOK       = "ok"
+	FileModified = "modified"
+	FileMissing  = "missing"
+)
+
+// InstalledFile is the verification result for one installed file.
+type InstalledFile struct {
+	Path   string // relative to .claude
+	Status string
+}
+
+// VerifyInstalled re-hashes the files a pack installed into the workspace
+// and compares them with the digests recorded at install time. A hook that
+// is still waiting for review is checked in the pending directory.
+func VerifyInstalled(workspace string, recorded map[string]string) []InstalledFile {
+	claudeDir := filepath.Join(workspace, ".claude")
+	var results []InstalledFile
+	for _, rel := range slices.Sorted(maps.Keys(recorded)) {
+		status := FileOK
+		path := filepath.Join(claudeDir, filepath.FromSlash(rel))
+		if name, ok := strings.CutPrefix(rel, "hooks/"); ok {
+			pending := PendingHookPath(workspace, strings.TrimSuffix(name, ".sh"))
+			if _, err := os.Stat(pending); err == nil {
+				path = pending
+			}
+		}
+		d, err := FileDigest(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			status = FileMissing
+		case err != nil || d != recorded[rel]:
+			status = FileModified
+		}
+		results = append(results, InstalledFile{Path: rel, Status: status})
+	}
+	return results
+}
+
+// WriteDigests computes the content digests of the pack in dir and writes
+// them into its pack.json, keeping the rest of the manifest as is. Sign
+// pack.json after running it.
+func WriteDigests(dir string) (map[string]string, error) {
+	m, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	digests, err := ComputeDigests(dir, m.Contents)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "pack.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := setJSONField(data, "digests", digests)
+	if err != nil {
+		return nil, err
+	}
+	return digests, os.WriteFile(path, updated, 0644)
+}
+
+// setJSONField sets a top-level key of a JSON object, keeping the order of
+// the existing keys, and returns the object re-indented with two spaces.
+func setJSONField(data []byte, key string, value any) ([]byte, error) {
+	o, err := parseJSONObject(data)
+	if err != nil {
+		return nil, fmt.Errorf("pack.json %w", err)
+	}
+	if err := o.set(key, value); err != nil {
+		return nil, err
+	}
+	return o.indent()
+}
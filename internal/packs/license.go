@@ -0,0 +1,289 @@
+package packs
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// LicenseExpr is a parsed SPDX license expression. A leaf has ID (and
+// optionally OrLater and Exception); an operator node has Op ("AND" or
+// "OR") and Args.
+type LicenseExpr struct {
+	ID        string
+	OrLater   bool
+	Exception string
+	Op        string
+	Args      []*LicenseExpr
+}
+
+// spdxID matches an SPDX license or exception identifier, including
+// LicenseRef- and DocumentRef- references.
+var spdxID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.\-]*$`)
+
+// knownLicenses are the SPDX identifiers packs commonly use. Others parse
+// but are reported as unrecognised, which usually means a typo.
+var knownLicenses = []string{
+	"0BSD", "AGPL-3.0-only", "AGPL-3.0-or-later", "Apache-2.0", "Artistic-2.0",
+	"BSD-2-Clause", "BSD-3-Clause", "BSL-1.0", "CC-BY-4.0", "CC-BY-SA-4.0",
+	"CC0-1.0", "EPL-2.0", "GPL-2.0-only", "GPL-2.0-or-later", "GPL-3.0-only",
+	"GPL-3.0-or-later", "ISC", "LGPL-2.1-only", "LGPL-2.1-or-later",
+	"LGPL-3.0-only", "LGPL-3.0-or-later", "MIT", "MIT-0", "MPL-2.0",
+	"Unlicense", "Zlib",
+	// Deprecated forms still seen in the wild.
+	"AGPL-3.0", "GPL-2.0", "GPL-3.0", "LGPL-2.1", "LGPL-3.0",
+}
+
+// ParseLicense parses an SPDX license expression such as
+// "MIT OR (Apache-2.0 WITH LLVM-exception)". Operators are case-insensitive;
+// AND binds tighter than OR.
+func ParseLicense(expr string) (*LicenseExpr, error) {
+	p := &licenseParser{tokens: tokenizeLicense(expr)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty license expression")
+	}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in license expression %q", p.tokens[p.pos], expr)
+	}
+	return e, nil
+}
+
+// IDs returns the license identifiers in the expression.
+func (e *LicenseExpr) IDs() []string {
+	if e.Op == "" {
+		return []string{e.ID}
+	}
+	var ids []string
+	for _, a := range e.Args {
+		ids = append(ids, a.IDs()...)
+	}
+	return ids
+}
+
+// Unknown returns the identifiers that are not recognised SPDX licenses.
+// LicenseRef- identifiers are custom by definition and not reported.
+func (e *LicenseExpr) Unknown() []string {
+	var unknown []string
+	for _, id := range e.IDs() {
+		if !strings.HasPrefix(id, "LicenseRef-") && !strings.HasPrefix(id, "DocumentRef-") &&
+			!slices.ContainsFunc(knownLicenses, func(k string) bool { return strings.EqualFold(k, id) }) {
+			unknown = append(unknown, id)
+		}
+	}
+	return unknown
+}
+
+// Satisfies reports whether the expression can be complied with using only
+// allowed licenses: one branch of an OR, every part of an AND. Exceptions
+// only add permissions, so "X WITH exception" is allowed when X is, and
+// "X+" is allowed when X is.
+func (e *LicenseExpr) Satisfies(allowed []string) bool {
+	switch e.Op {
+	case "AND":
+		for _, a := range e.Args {
+			if !a.Satisfies(allowed) {
+				return false
+			}
+		}
+		return true
+	case "OR":
+		return slices.ContainsFunc(e.Args, func(a *LicenseExpr) bool { return a.Satisfies(allowed) })
+	}
+	return slices.ContainsFunc(allowed, func(l string) bool {
+		return strings.EqualFold(l, e.ID) || strings.EqualFold(l, e.String())
+	})
+}
+
+// String renders the expression in canonical form.
+func (e *LicenseExpr) String() string {
+	if e.Op == "" {
+		s := e.ID
+		if e.OrLater {
+			s += "+"
+		}
+		if e.Exception != "" {
+			s += " WITH " + e.Exception
+		}
+		return s
+	}
+	parts := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		parts[i] = a.String()
+		if a.Op != "" && a.Op != e.Op {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, " "+e.Op+" ")
+}
+
+// LicenseAllowed checks a declared license against an allowlist. An empty
+// license is never allowed; an unparsable one is allowed only if listed
+// verbatim.
+func LicenseAllowed(license string, allowed []string) bool {
+	if license == "" {
+		return false
+	}
+	e, err := ParseLicense(license)
+	if err != nil {
+		return slices.ContainsFunc(allowed, func(l string) bool { return strings.EqualFold(l, license) })
+	}
+	return e.Satisfies(allowed)
+}
+
+func tokenizeLicense(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+type licenseParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *licenseParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *licenseParser) or() (*LicenseExpr, error) {
+	return p.binary("OR", p.and)
+}
+
+func (p *licenseParser) and() (*LicenseExpr, error) {
+	return p.binary("AND", p.term)
+}
+
+func (p *licenseParser) binary(op string, next func() (*LicenseExpr, error)) (*LicenseExpr, error) {
+	first, err := next()
+	if err != nil {
+		return nil, err
+	}
+	args := []*LicenseExpr{first}
+	for strings.EqualFold(p.peek(), op) {
+		p.pos++
+		e, err := next()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+	}
+	if len(args) == 1 {
+		return first, nil
+	}
+	return &LicenseExpr{Op: op, Args: args}, nil
+}
+
+func (p *licenseParser) term() (*LicenseExpr, error) {
+	tok := p.peek()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("license expression ends unexpectedly")
+	case tok == "(":
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ) in license expression")
+		}
+		p.pos++
+		return e, nil
+	case isLicenseOperator(tok) || tok == ")":
+		return nil, fmt.Errorf("expected a license identifier, got %q", tok)
+	}
+	p.pos++
+	e := &LicenseExpr{ID: tok}
+	if id, ok := strings.CutSuffix(tok, "+"); ok {
+		e.ID, e.OrLater = id, true
+	}
+	if !spdxID.MatchString(e.ID) {
+		return nil, fmt.Errorf("invalid license identifier %q", tok)
+	}
+	if strings.EqualFold(p.peek(), "WITH") {
+		p.pos++
+		exc := p.peek()
+		if exc == "" || isLicenseOperator(exc) || !spdxID.MatchString(exc) {
+			return nil, fmt.Errorf("expected an exception after WITH, got %q", exc)
+		}
+		p.pos++
+		e.Exception = exc
+	}
+	return e, nil
+}
+
+func isLicenseOperator(tok string) bool {
+	return strings.EqualFold(tok, "AND") || strings.EqualFold(tok, "OR") || strings.EqualFold(tok, "WITH")
+}
+
+// License report statuses.
+const (
+	LicenseOK         = "allowed"
+	LicenseDisallowed = "disallowed"
+	LicenseMissing    = "missing"
+	LicenseInvalid    = "invalid"
+	LicenseUnchecked  = "unchecked"
+)
+
+// LicenseRow is one pack in a license report.
+type LicenseRow struct {
+	Pack    string `json:"pack"`
+	Version string `json:"version"`
+	Repo    string `json:"repo"`
+	License string `json:"license"`
+	Status  string `json:"status"`
+	Note    string `json:"note,omitempty"`
+}
+
+// CheckLicense grades a declared license against allowlists, each of which
+// must be satisfied. With no allowlists only missing and invalid licenses
+// are flagged.
+func CheckLicense(license string, allowlists [][]string) (status, note string) {
+	if license == "" {
+		return LicenseMissing, "no license declared"
+	}
+	e, err := ParseLicense(license)
+	if err != nil {
+		return LicenseInvalid, err.Error()
+	}
+	if unknown := e.Unknown(); len(unknown) > 0 {
+		note = "unrecognised SPDX identifier: " + strings.Join(unknown, ", ")
+	}
+	if len(allowlists) == 0 {
+		return LicenseUnchecked, note
+	}
+	for _, allowed := range allowlists {
+		if !e.Satisfies(allowed) {
+			return LicenseDisallowed, joinNotes("not in allowlist "+strings.Join(allowed, ", "), note)
+		}
+	}
+	return LicenseOK, note
+}
+
+// WriteLicenseCSV writes report rows as CSV with a header line.
+func WriteLicenseCSV(w io.Writer, rows []LicenseRow) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"pack", "version", "repo", "license", "status", "note"})
+	for _, r := range rows {
+		cw.Write([]string{r.Pack, r.Version, r.Repo, r.License, r.Status, r.Note})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// joinNotes joins two report notes, either of which may be empty.
+func joinNotes(a, b string) string {
+	if b == "" {
+		return a
+	}
+	return a + "; " + b
+}
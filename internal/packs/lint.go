@@ -0,0 +1,473 @@
+package packs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/orchestra-mcp/sdk-go/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+// Lint severities, from most to least serious.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Lint limits for pack files.
+const (
+	lintWarnFileSize  = 100 << 10 // files above this are flagged
+	lintMaxSkillLines = 500       // SKILL.md files longer than this are flagged
+)
+
+// Frontmatter fields accepted in SKILL.md and agent files.
+var (
+	skillFields = []string{"name", "description", "allowed-tools", "license", "metadata", "model", "version", "argument-hint", "disable-model-invocation", "user-invocable"}
+	agentFields = []string{"name", "description", "tools", "disallowedTools", "model", "color", "permissionMode", "skills", "hooks"}
+)
+
+// LintIssue is one finding, located by pack-relative file and 1-based line
+// (0 when the finding applies to the whole file).
+type LintIssue struct {
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Rule     string `json:"rule"`
+	Message  string `json:"message"`
+}
+
+// Location renders the issue position as file:line.
+func (i LintIssue) Location() string {
+	if i.Line > 0 {
+		return fmt.Sprintf("%s:%d", i.File, i.Line)
+	}
+	return i.File
+}
+
+// LintReport is the result of linting a pack directory.
+type LintReport struct {
+	Dir      string        `json:"dir"`
+	Manifest *PackManifest `json:"manifest,omitempty"`
+	Issues   []LintIssue   `json:"issues"`
+}
+
+// Count returns the number of issues with the given severity.
+func (r *LintReport) Count(severity string) int {
+	n := 0
+	for _, i := range r.Issues {
+		if i.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// LintPack checks a pack directory before publishing: manifest validation,
+// skill and agent frontmatter, file sizes, relative links in markdown, hook
+// shebangs, hook fixtures, and workflow definitions. Issues are sorted by
+// file and line.
+func LintPack(dir string) (*LintReport, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	l := &linter{dir: dir, report: &LintReport{Dir: dir}}
+	l.lintManifest()
+	l.lintSizes()
+	l.lintSkills()
+	l.lintAgents()
+	l.lintHooks()
+	l.lintHookFixtures()
+	l.lintWorkflows()
+
+	sort.SliceStable(l.report.Issues, func(a, b int) bool {
+		ia, ib := l.report.Issues[a], l.report.Issues[b]
+		if ia.File != ib.File {
+			return ia.File < ib.File
+		}
+		return ia.Line < ib.Line
+	})
+	return l.report, nil
+}
+
+type linter struct {
+	dir    string
+	report *LintReport
+}
+
+func (l *linter) add(severity, file string, line int, rule, format string, args ...any) {
+	l.report.Issues = append(l.report.Issues, LintIssue{
+		Severity: severity,
+		File:     filepath.ToSlash(file),
+		Line:     line,
+		Rule:     rule,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// lintManifest runs the install-time manifest validation and locates each
+// problem in pack.json.
+func (l *linter) lintManifest() {
+	data, err := os.ReadFile(filepath.Join(l.dir, "pack.json"))
+	if err != nil {
+		l.add(SeverityError, "pack.json", 0, "manifest", "pack.json is missing")
+		return
+	}
+	m, err := ParseManifest(data, l.dir)
+	if err == nil {
+		l.report.Manifest = m
+		if m.Description == "" {
+			l.add(SeverityWarning, "pack.json", 0, "manifest", "description is empty; it is shown in search results")
+		}
+		if m.License == "" {
+			l.add(SeverityInfo, "pack.json", 0, "manifest", "no license declared")
+		} else if e, err := ParseLicense(m.License); err != nil {
+			l.add(SeverityWarning, "pack.json", jsonFieldLine(data, "license"), "license", "license is not a valid SPDX expression: %v", err)
+		} else if unknown := e.Unknown(); len(unknown) > 0 {
+			l.add(SeverityInfo, "pack.json", jsonFieldLine(data, "license"), "license", "unrecognised SPDX license identifier %s", strings.Join(unknown, ", "))
+		}
+		for _, u := range CheckPackFiles(l.dir, m) {
+			if !u.Oversize { // reported by lintSizes
+				l.add(SeverityError, u.Path, 0, "unsafe", "%s; install_pack rejects the pack", u.Reason)
+			}
+		}
+		if len(m.Digests) > 0 {
+			if actual, err := ComputeDigests(l.dir, m.Contents); err == nil {
+				for _, problem := range compareDigests(m.Digests, actual, "declared in pack.json") {
+					l.add(SeverityError, "pack.json", jsonFieldLine(data, "digests"), "digest", "%s; run `tools-marketplace digest` and sign again", problem)
+				}
+			}
+		}
+		return
+	}
+	var me *ManifestError
+	if !errors.As(err, &me) {
+		l.add(SeverityError, "pack.json", 0, "manifest", "%v", err)
+		return
+	}
+	for _, fe := range me.Errors {
+		l.add(SeverityError, "pack.json", jsonFieldLine(data, fe.Field), "manifest", "%s", fe.Error())
+	}
+}
+
+// lintSizes flags oversized files anywhere in the pack.
+func (l *linter) lintSizes() {
+	filepath.WalkDir(l.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(l.dir, path)
+		switch {
+		case info.Size() > MaxPackFileSize:
+			l.add(SeverityError, rel, 0, "file-size", "file is %s (limit %s)", FormatSize(info.Size()), FormatSize(MaxPackFileSize))
+		case info.Size() > lintWarnFileSize:
+			l.add(SeverityWarning, rel, 0, "file-size", "file is %s; large files bloat every workspace that installs the pack", FormatSize(info.Size()))
+		}
+		return nil
+	})
+}
+
+func (l *linter) lintSkills() {
+	entries, _ := os.ReadDir(filepath.Join(l.dir, "skills"))
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		rel := filepath.Join("skills", e.Name(), "SKILL.md")
+		data, err := os.ReadFile(filepath.Join(l.dir, rel))
+		if err != nil {
+			continue // missing SKILL.md is a manifest error
+		}
+		l.lintFrontmatter(rel, data, e.Name(), skillFields)
+		if lines := bytes.Count(data, []byte("\n")); lines > lintMaxSkillLines {
+			l.add(SeverityWarning, rel, 0, "file-size", "SKILL.md has %d lines; keep it under %d and move detail into linked files", lines, lintMaxSkillLines)
+		}
+
+		// Every markdown file in the skill may link to its siblings.
+		filepath.WalkDir(filepath.Join(l.dir, "skills", e.Name()), func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.HasSuffix(d.Name(), ".md") {
+				if data, err := os.ReadFile(path); err == nil {
+					r, _ := filepath.Rel(l.dir, path)
+					l.lintLinks(r, data)
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func (l *linter) lintAgents() {
+	entries, _ := os.ReadDir(filepath.Join(l.dir, "agents"))
+	for _, e := range entries {
+		slug, ok := strings.CutSuffix(e.Name(), ".md")
+		if e.IsDir() || !ok {
+			continue
+		}
+		rel := filepath.Join("agents", e.Name())
+		data, err := os.ReadFile(filepath.Join(l.dir, rel))
+		if err != nil {
+			continue
+		}
+		l.lintFrontmatter(rel, data, slug, agentFields)
+		l.lintLinks(rel, data)
+	}
+}
+
+// lintFrontmatter checks that a skill or agent file starts with YAML
+// frontmatter holding a name and description and only known fields.
+func (l *linter) lintFrontmatter(rel string, data []byte, slug string, allowed []string) {
+	block, _, offset, ok := splitFrontmatter(string(data))
+	if !ok {
+		l.add(SeverityError, rel, 1, "frontmatter", "missing YAML frontmatter (--- name/description ---)")
+		return
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(block), &doc); err != nil {
+		line := offset + 1
+		if n := yamlErrorLine(err); n > 0 {
+			line = offset + n
+		}
+		l.add(SeverityError, rel, line, "frontmatter", "invalid YAML: %v", err)
+		return
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		l.add(SeverityError, rel, offset+1, "frontmatter", "frontmatter must be a mapping of fields")
+		return
+	}
+
+	fields := map[string]*yaml.Node{}
+	mapping := doc.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, val := mapping.Content[i], mapping.Content[i+1]
+		fields[key.Value] = val
+		if !slices.Contains(allowed, key.Value) {
+			l.add(SeverityWarning, rel, offset+key.Line, "frontmatter", "unknown field %q (allowed: %s)", key.Value, strings.Join(allowed, ", "))
+		}
+	}
+
+	for _, required := range []string{"name", "description"} {
+		val, ok := fields[required]
+		switch {
+		case !ok:
+			l.add(SeverityError, rel, offset, "frontmatter", "missing required field %q", required)
+		case val.Kind != yaml.ScalarNode || strings.TrimSpace(val.Value) == "":
+			l.add(SeverityError, rel, offset+val.Line, "frontmatter", "%q must be a non-empty string", required)
+		}
+	}
+	if name, ok := fields["name"]; ok && name.Kind == yaml.ScalarNode && name.Value != "" {
+		if !slugPattern.MatchString(name.Value) {
+			l.add(SeverityWarning, rel, offset+name.Line, "frontmatter", "name %q should be a lowercase slug", name.Value)
+		} else if name.Value != slug {
+			l.add(SeverityWarning, rel, offset+name.Line, "frontmatter", "name %q does not match file name %q", name.Value, slug)
+		}
+	}
+	if desc, ok := fields["description"]; ok && len(desc.Value) > 1024 {
+		l.add(SeverityWarning, rel, offset+desc.Line, "frontmatter", "description is %d characters; keep it under 1024", len(desc.Value))
+	}
+}
+
+// markdownLink matches inline links and images: [text](target "title").
+var markdownLink = regexp.MustCompile(`!?\[[^\]]*\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+
+// lintLinks reports relative links that point at files missing from the pack
+// or outside it. Links inside fenced code blocks are ignored.
+func (l *linter) lintLinks(rel string, data []byte) {
+	base := filepath.Dir(filepath.Join(l.dir, rel))
+	inFence := false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := sc.Text()
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		for _, m := range markdownLink.FindAllStringSubmatch(line, -1) {
+			target := m[1]
+			if target == "" || strings.HasPrefix(target, "#") || strings.HasPrefix(target, "/") ||
+				strings.Contains(target, "://") || strings.HasPrefix(target, "mailto:") {
+				continue
+			}
+			target, _, _ = strings.Cut(target, "#")
+			resolved := filepath.Join(base, filepath.FromSlash(target))
+			if inside, _ := filepath.Rel(l.dir, resolved); !filepath.IsLocal(inside) {
+				l.add(SeverityError, rel, n, "broken-link", "link %q points outside the pack", m[1])
+				continue
+			}
+			if _, err := os.Stat(resolved); err != nil {
+				l.add(SeverityError, rel, n, "broken-link", "link target %q does not exist", m[1])
+			}
+		}
+	}
+}
+
+// lintHooks requires every hook script to start with a shebang and warns
+// about high-risk constructs.
+func (l *linter) lintHooks() {
+	entries, _ := os.ReadDir(filepath.Join(l.dir, "hooks"))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".sh") {
+			continue
+		}
+		rel := filepath.Join("hooks", e.Name())
+		data, err := os.ReadFile(filepath.Join(l.dir, rel))
+		if err != nil {
+			continue
+		}
+		if !bytes.HasPrefix(data, []byte("#!")) {
+			l.add(SeverityError, rel, 1, "hook-shebang", "hook script must start with a shebang (e.g. #!/usr/bin/env bash)")
+		}
+		if bytes.Contains(data, []byte("\r\n")) {
+			l.add(SeverityWarning, rel, 1, "hook-shebang", "hook script has CRLF line endings, which break the interpreter line")
+		}
+		for _, f := range ScanHook(data).Findings {
+			if f.Level >= RiskHigh {
+				l.add(SeverityWarning, rel, f.Line, "hook-risk", "%s (%s risk); installs may be blocked by policy", f.Message, f.Level)
+			}
+		}
+	}
+}
+
+// lintHookFixtures runs the fixture payloads under fixtures/hooks/ against
+// the pack's hooks in the test sandbox. It needs a valid manifest to know
+// which hooks exist and what events they declare.
+func (l *linter) lintHookFixtures() {
+	m := l.report.Manifest
+	if m == nil {
+		if _, err := os.Stat(filepath.Join(l.dir, "fixtures", "hooks")); err == nil {
+			l.add(SeverityInfo, "fixtures/hooks", 0, "hook-test", "hook fixtures were not run because pack.json is invalid")
+		}
+		return
+	}
+	for _, r := range RunHookFixtures(context.Background(), l.dir, m) {
+		switch {
+		case r.Err != nil:
+			l.add(SeverityError, r.File, 0, "hook-test", "%v", r.Err)
+		case len(r.Failures) > 0:
+			l.add(SeverityError, r.File, 0, "hook-test", "hook %s: %s", r.Hook, strings.Join(r.Failures, "; "))
+		}
+	}
+}
+
+// lintWorkflows loads each workflow definition and checks that its states,
+// transitions, and gates refer to each other consistently.
+func (l *linter) lintWorkflows() {
+	entries, _ := os.ReadDir(filepath.Join(l.dir, "workflow"))
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		rel := filepath.Join("workflow", e.Name())
+		def, err := workflow.LoadFromFile(filepath.Join(l.dir, rel))
+		if err != nil {
+			l.add(SeverityError, rel, yamlErrorLine(err), "workflow", "%v", err)
+			continue
+		}
+		for _, msg := range workflowProblems(def) {
+			l.add(SeverityError, rel, 0, "workflow", "%s", msg)
+		}
+	}
+}
+
+// workflowProblems returns the consistency errors in a workflow definition.
+func workflowProblems(def *workflow.WorkflowDefinition) []string {
+	var problems []string
+	if def.Name == "" {
+		problems = append(problems, "name is required")
+	}
+	if len(def.States) == 0 {
+		problems = append(problems, "no states defined")
+	}
+	if def.InitialState == "" {
+		problems = append(problems, "initial_state is required")
+	} else if _, ok := def.States[def.InitialState]; !ok {
+		problems = append(problems, fmt.Sprintf("initial_state %q is not a defined state", def.InitialState))
+	}
+	for i, t := range def.Transitions {
+		from, okFrom := def.States[workflow.StateID(t.From)]
+		if !okFrom {
+			problems = append(problems, fmt.Sprintf("transitions[%d]: from state %q is not defined", i, t.From))
+		} else if from.Terminal {
+			problems = append(problems, fmt.Sprintf("transitions[%d]: terminal state %q cannot have outgoing transitions", i, t.From))
+		}
+		if _, ok := def.States[workflow.StateID(t.To)]; !ok {
+			problems = append(problems, fmt.Sprintf("transitions[%d]: to state %q is not defined", i, t.To))
+		}
+		if t.Gate != "" {
+			if _, ok := def.Gates[t.Gate]; !ok {
+				problems = append(problems, fmt.Sprintf("transitions[%d]: gate %q is not defined", i, t.Gate))
+			}
+		}
+	}
+	return problems
+}
+
+var yamlLine = regexp.MustCompile(`line (\d+)`)
+
+// yamlErrorLine extracts the line number from a YAML parse error, or 0.
+func yamlErrorLine(err error) int {
+	m := yamlLine.FindStringSubmatch(err.Error())
+	if m == nil {
+		return 0
+	}
+	var n int
+	fmt.Sscanf(m[1], "%d", &n)
+	return n
+}
+
+// jsonFieldLine finds the line in a JSON document where a manifest field path
+// such as "contents.skills[1]" is declared, or 0 if it cannot be located.
+func jsonFieldLine(data []byte, field string) int {
+	if field == "" {
+		return 0
+	}
+	key := field
+	if i := strings.LastIndexByte(key, '.'); i >= 0 {
+		key = key[i+1:]
+	}
+	key, _, _ = strings.Cut(key, "[")
+	idx := bytes.Index(data, []byte(`"`+key+`"`))
+	if idx < 0 {
+		return 0
+	}
+	line, _ := lineCol(data, int64(idx))
+	return line
+}
+
+// FormatSize renders a byte count as B, KiB, or MiB.
+func FormatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,180 @@
+package packs
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ScopeWorkspace is the scope of content that exists only as a workspace
+// file; storage entries carry their own.
+const ScopeWorkspace = "workspace"
+
+// ContentEntry is a skill, agent, or hook as the list tools show it, merged
+// from its workspace file and its storage entry.
+type ContentEntry struct {
+	Kind        string
+	Slug        string
+	Name        string
+	Description string
+	Model       string
+	Tools       []string // tools, or a skill's allowed-tools
+	Scope       string
+	Pack        string
+	Provenance  *Provenance // nil unless the workspace file came from a pack
+	Tags        []string
+	Size        int       // bytes of the file, or of the stored body without one
+	ModTime     time.Time // the later of the two sides
+	InFiles     bool
+	InStorage   bool
+	Differs     bool // in both places with different content
+}
+
+// ContentEntries merges the workspace files and storage entries of one kind,
+// both keyed by slug, into entries sorted by slug. Storage-only metadata
+// such as scope does not make the two sides differ.
+func ContentEntries(kind string, files map[string]*SyncFile, stored map[string]*SyncStored, prov map[string]*Provenance) []ContentEntry {
+	slugs := make(map[string]bool)
+	for slug := range files {
+		slugs[slug] = true
+	}
+	for slug := range stored {
+		slugs[slug] = true
+	}
+	entries := make([]ContentEntry, 0, len(slugs))
+	for _, slug := range slices.Sorted(maps.Keys(slugs)) {
+		entries = append(entries, contentEntry(kind, slug, files[slug], stored[slug], prov[SyncKey(kind, slug)]))
+	}
+	return entries
+}
+
+func contentEntry(kind, slug string, f *SyncFile, s *SyncStored, p *Provenance) ContentEntry {
+	e := ContentEntry{Kind: kind, Slug: slug, Scope: ScopeWorkspace, Provenance: p}
+	var fields map[string]any
+	if s != nil {
+		e.InStorage = true
+		e.Size = len(s.Body)
+		e.ModTime = s.ModTime
+		fields = s.Metadata
+		e.Scope = cmp.Or(metaString(s.Metadata, "scope"), e.Scope)
+		e.Pack = metaString(s.Metadata, "pack")
+	}
+	if f != nil {
+		e.InFiles = true
+		e.Size = len(f.Content)
+		if f.ModTime.After(e.ModTime) {
+			e.ModTime = f.ModTime
+		}
+		var meta map[string]any
+		if s != nil {
+			meta = s.Metadata
+		}
+		body, fileMeta := StoredFromFile(kind, slug, f.Content, meta)
+		if s != nil {
+			e.Differs = StoredHash(kind, body, fileMeta) != StoredHash(kind, s.Body, s.Metadata)
+		}
+		if !storedVerbatim(kind) {
+			fields = fileMeta // the file's frontmatter over storage-only fields
+		}
+	}
+	if p != nil {
+		e.Pack = p.Pack
+	}
+	e.Name = cmp.Or(metaString(fields, "name"), slug)
+	e.Description = strings.Join(strings.Fields(metaString(fields, "description")), " ")
+	e.Model = metaString(fields, "model")
+	e.Tools = metaList(fields["tools"])
+	if len(e.Tools) == 0 {
+		e.Tools = metaList(fields["allowed-tools"])
+	}
+	e.Tags = metaList(fields["tags"])
+	return e
+}
+
+// ContentFilter selects list entries. Empty fields match everything.
+type ContentFilter struct {
+	Pack  string
+	Scope string
+	Tag   string
+	Text  string // matched case-insensitively against slug, name, and description
+}
+
+// Match reports whether an entry passes the filter.
+func (f ContentFilter) Match(e ContentEntry) bool {
+	if f.Pack != "" && e.Pack != f.Pack {
+		return false
+	}
+	if f.Scope != "" && e.Scope != f.Scope {
+		return false
+	}
+	if f.Tag != "" && !slices.ContainsFunc(e.Tags, func(t string) bool { return strings.EqualFold(t, f.Tag) }) {
+		return false
+	}
+	if f.Text != "" {
+		text := strings.ToLower(f.Text)
+		if !strings.Contains(strings.ToLower(e.Slug+"\n"+e.Name+"\n"+e.Description), text) {
+			return false
+		}
+	}
+	return true
+}
+
+// ContentSorts are the orders SortContent accepts. Sorting by name is
+// ascending by default, the others start with the largest or newest.
+var ContentSorts = []string{"name", "modified", "size", "pack", "scope"}
+
+// SortContent sorts entries by one of ContentSorts, reversing the default
+// direction when reverse is set. Ties are broken by slug.
+func SortContent(entries []ContentEntry, by string, reverse bool) error {
+	var compare func(a, b ContentEntry) int
+	switch by {
+	case "", "name":
+		compare = func(a, b ContentEntry) int { return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)) }
+	case "modified":
+		compare = func(a, b ContentEntry) int { return b.ModTime.Compare(a.ModTime) }
+	case "size":
+		compare = func(a, b ContentEntry) int { return cmp.Compare(b.Size, a.Size) }
+	case "pack":
+		compare = func(a, b ContentEntry) int { return strings.Compare(a.Pack, b.Pack) }
+	case "scope":
+		compare = func(a, b ContentEntry) int { return strings.Compare(a.Scope, b.Scope) }
+	default:
+		return fmt.Errorf("unknown sort %q (want one of %s)", by, strings.Join(ContentSorts, ", "))
+	}
+	slices.SortStableFunc(entries, func(a, b ContentEntry) int {
+		c := cmp.Or(compare(a, b), strings.Compare(a.Slug, b.Slug))
+		if reverse {
+			return -c
+		}
+		return c
+	})
+	return nil
+}
+
+func metaString(meta map[string]any, key string) string {
+	s, _ := meta[key].(string)
+	return strings.TrimSpace(s)
+}
+
+// metaList reads a list field stored as a list or a comma-separated string.
+func metaList(v any) []string {
+	var list []string
+	switch v := v.(type) {
+	case string:
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				list = append(list, strings.TrimSpace(s))
+			}
+		}
+	}
+	return list
+}
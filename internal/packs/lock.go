@@ -0,0 +1,252 @@
+package packs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultLockStaleAfter is how long a lock file may be held before other
+// processes consider it abandoned, even if its PID still appears alive.
+const DefaultLockStaleAfter = 15 * time.Minute
+
+// lockPollInterval is how often a waiting caller re-checks the lock file.
+const lockPollInterval = 50 * time.Millisecond
+
+// lockUnreadableGrace is how long a lock file that cannot be parsed is left
+// alone, in case its writer is an older plugin that creates the file before
+// writing it. After that it is treated as corrupt and broken.
+const lockUnreadableGrace = 2 * time.Second
+
+// LockInfo is the content of the workspace lock file.
+type LockInfo struct {
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	Operation  string    `json:"operation"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// BusyError is returned when the workspace lock could not be acquired before
+// the deadline. Holder describes the operation currently holding it.
+type BusyError struct {
+	Holder LockInfo
+}
+
+func (e *BusyError) Error() string {
+	return fmt.Sprintf("workspace is busy: %s (pid %d on %s) has held the pack lock since %s",
+		e.Holder.Operation, e.Holder.PID, e.Holder.Host, e.Holder.AcquiredAt.Format(time.RFC3339))
+}
+
+// WorkspaceLock serialises mutating pack operations on a workspace. It
+// combines an in-process mutex, for concurrent tool calls within the plugin,
+// with an advisory lock file under .claude/ for other processes.
+type WorkspaceLock struct {
+	path       string
+	staleAfter time.Duration
+
+	sem    chan struct{}
+	mu     sync.Mutex
+	holder LockInfo
+}
+
+// NewWorkspaceLock returns the lock for the given workspace.
+func NewWorkspaceLock(workspace string) *WorkspaceLock {
+	return &WorkspaceLock{
+		path:       filepath.Join(workspace, ".claude", ".packs.lock"),
+		staleAfter: DefaultLockStaleAfter,
+		sem:        make(chan struct{}, 1),
+	}
+}
+
+// Acquire takes the lock for the named operation, waiting up to wait for it
+// to become free. A wait of zero fails immediately if the lock is held. The
+// returned function releases the lock. If the deadline passes, the error is
+// a *BusyError naming the holder.
+func (l *WorkspaceLock) Acquire(ctx context.Context, operation string, wait time.Duration) (func(), error) {
+	deadline := time.Now().Add(wait)
+
+	if err := l.acquireLocal(ctx, wait); err != nil {
+		return nil, err
+	}
+	info, err := l.acquireFile(ctx, operation, deadline)
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.holder = info
+	l.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			l.holder = LockInfo{}
+			l.mu.Unlock()
+			l.removeIfOwned(info)
+			<-l.sem
+		})
+	}, nil
+}
+
+// acquireLocal takes the in-process semaphore.
+func (l *WorkspaceLock) acquireLocal(ctx context.Context, wait time.Duration) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	default:
+	}
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case l.sem <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	l.mu.Lock()
+	holder := l.holder
+	l.mu.Unlock()
+	return &BusyError{Holder: holder}
+}
+
+// acquireFile creates the lock file exclusively, breaking stale locks and
+// polling until the deadline while another live process holds it.
+func (l *WorkspaceLock) acquireFile(ctx context.Context, operation string, deadline time.Time) (LockInfo, error) {
+	host, _ := os.Hostname()
+	info := LockInfo{PID: os.Getpid(), Host: host, Operation: operation}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return LockInfo{}, fmt.Errorf("create lock dir: %w", err)
+	}
+
+	for {
+		info.AcquiredAt = time.Now().UTC()
+		created, err := l.tryCreate(info)
+		if err != nil {
+			return LockInfo{}, err
+		}
+		if created {
+			return info, nil
+		}
+
+		holder, err := l.readHolder()
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			// Released between our create and read; retry.
+			continue
+		case err != nil:
+			holder = LockInfo{Operation: "unknown"}
+			if fi, serr := os.Stat(l.path); serr == nil && time.Since(fi.ModTime()) > lockUnreadableGrace {
+				l.breakLock(nil)
+				continue
+			}
+		case l.isStale(holder):
+			l.breakLock(&holder)
+			continue
+		}
+
+		if !time.Now().Before(deadline) {
+			return LockInfo{}, &BusyError{Holder: holder}
+		}
+		select {
+		case <-ctx.Done():
+			return LockInfo{}, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// tryCreate writes the lock file if it does not already exist. The content
+// is written to a temporary file first and linked into place, so other
+// processes never see the lock file empty or half-written.
+func (l *WorkspaceLock) tryCreate(info LockInfo) (bool, error) {
+	f, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return false, fmt.Errorf("create lock file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	data, _ := json.Marshal(info)
+	_, werr := f.Write(data)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		return false, fmt.Errorf("write lock file: %w", errors.Join(werr, cerr))
+	}
+	os.Chmod(f.Name(), 0644)
+	if err := os.Link(f.Name(), l.path); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("create lock file: %w", err)
+	}
+	return true, nil
+}
+
+// breakLock removes the lock file if it still records holder, or is still
+// unreadable when holder is nil. The file is renamed aside before it is
+// checked, so a lock another process re-took after holder was read is
+// never deleted; it is linked back into place instead.
+func (l *WorkspaceLock) breakLock(holder *LockInfo) {
+	aside := fmt.Sprintf("%s.%d.%d.broken", l.path, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(l.path, aside); err != nil {
+		return // already broken or released by someone else
+	}
+	defer os.Remove(aside)
+	current, err := readLockInfo(aside)
+	if (holder == nil && err != nil) || (holder != nil && err == nil && sameHolder(current, *holder)) {
+		return
+	}
+	os.Link(aside, l.path)
+}
+
+// readHolder reads the current lock file.
+func (l *WorkspaceLock) readHolder() (LockInfo, error) {
+	return readLockInfo(l.path)
+}
+
+func readLockInfo(path string) (LockInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LockInfo{}, err
+	}
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return LockInfo{}, err
+	}
+	return info, nil
+}
+
+func sameHolder(a, b LockInfo) bool {
+	return a.PID == b.PID && a.Host == b.Host && a.AcquiredAt.Equal(b.AcquiredAt)
+}
+
+// isStale reports whether a lock file was left behind by a crashed process
+// or has outlived the stale timeout.
+func (l *WorkspaceLock) isStale(holder LockInfo) bool {
+	if holder.AcquiredAt.IsZero() || time.Since(holder.AcquiredAt) > l.staleAfter {
+		return true
+	}
+	host, _ := os.Hostname()
+	if holder.Host == host && holder.PID > 0 && !processAlive(holder.PID) {
+		return true
+	}
+	return false
+}
+
+// removeIfOwned deletes the lock file only if it still records our
+// acquisition, so a lock broken as stale and re-taken elsewhere survives.
+func (l *WorkspaceLock) removeIfOwned(info LockInfo) {
+	if current, err := l.readHolder(); err == nil && sameHolder(current, info) {
+		l.breakLock(&info)
+	}
+}
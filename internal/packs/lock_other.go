@@ -0,0 +1,9 @@
+//go:build !unix
+
+package packs
+
+// processAlive cannot probe other processes on this platform, so locks are
+// only broken once they exceed the stale timeout.
+func processAlive(pid int) bool {
+	return true
+}
@@ -0,0 +1,458 @@
+package packs
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ManifestSchemaID is the $id of the pack.json schema this version validates.
+const ManifestSchemaID = "https://raw.githubusercontent.com/orchestra-mcp/plugin-tools-marketplace/master/internal/packs/schema/pack.v1.json"
+
+// Pack types accepted in pack.json.
+const (
+	TypePack   = "pack"
+	TypeBundle = "bundle"
+)
+
+//go:embed schema/pack.v1.json
+var manifestSchema []byte
+
+// ManifestSchema returns the JSON Schema for pack.json.
+func ManifestSchema() []byte {
+	return manifestSchema
+}
+
+var (
+	slugPattern     = regexp.MustCompile(`^[a-z0-9]+(?:[-_][a-z0-9]+)*$`)
+	packNamePattern = regexp.MustCompile(`^[a-z0-9]+(?:[-_.][a-z0-9]+)*/[a-z0-9]+(?:[-_.][a-z0-9]+)*$`)
+	workflowPattern = regexp.MustCompile(`^[a-z0-9]+(?:[-_][a-z0-9]+)*\.ya?ml$`)
+	schemaVersion   = regexp.MustCompile(`pack\.v(\d+)\.json$`)
+)
+
+// ValidSlug reports whether name is a valid skill, agent, or hook slug.
+func ValidSlug(name string) bool {
+	return slugPattern.MatchString(name)
+}
+
+// FieldError is one problem with a manifest, located by a field path such as
+// "contents.skills[1]".
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+// ManifestError lists every problem found in a pack.json.
+type ManifestError struct {
+	Errors []FieldError
+}
+
+func (e *ManifestError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Error()
+	}
+	return "invalid pack.json: " + strings.Join(msgs, "; ")
+}
+
+// manifestFields are the top-level pack.json keys and their JSON kinds.
+var manifestFields = []struct {
+	name string
+	kind string // "string", "strings", "object" (contents), "map" (of strings), or "events"
+}{
+	{"$schema", "string"},
+	{"name", "string"},
+	{"description", "string"},
+	{"version", "string"},
+	{"type", "string"},
+	{"license", "string"},
+	{"stacks", "strings"},
+	{"requires", "strings"},
+	{"contents", "object"},
+	{"tags", "strings"},
+	{"changelog", "string"},
+	{"digests", "map"},
+	{"hook_events", "events"},
+}
+
+// hookEventFields are the keys of a hook_events entry and their JSON kinds.
+var hookEventFields = map[string]string{"event": "string", "matcher": "string", "timeout": "number"}
+
+var contentFields = []string{"skills", "agents", "hooks", "workflows"}
+
+// ParseManifest decodes and validates a pack.json. If dir is not empty it is
+// the pack's root directory, and the declared contents are checked against
+// the files in it: every declared item must exist and nothing undeclared may
+// sit in the content directories. All problems are reported together in a
+// *ManifestError.
+func ParseManifest(data []byte, dir string) (*PackManifest, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var syntax *json.SyntaxError
+		if errors.As(err, &syntax) {
+			line, col := lineCol(data, syntax.Offset)
+			return nil, &ManifestError{Errors: []FieldError{{Message: fmt.Sprintf("line %d, column %d: %v", line, col, err)}}}
+		}
+		return nil, &ManifestError{Errors: []FieldError{{Message: "must be a JSON object"}}}
+	}
+
+	v := &manifestValidator{}
+	v.checkShape(raw)
+	if len(v.errs) > 0 {
+		return nil, &ManifestError{Errors: v.errs}
+	}
+
+	var m PackManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, &ManifestError{Errors: []FieldError{{Message: err.Error()}}}
+	}
+	v.checkValues(&m)
+	if dir != "" {
+		v.checkFiles(&m, dir)
+	}
+	if len(v.errs) > 0 {
+		return nil, &ManifestError{Errors: v.errs}
+	}
+	if m.Type == "" {
+		m.Type = TypePack
+	}
+	return &m, nil
+}
+
+// ReadManifest reads and validates dir/pack.json, including its contents.
+func ReadManifest(dir string) (*PackManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "pack.json"))
+	if err != nil {
+		return nil, fmt.Errorf("read pack.json: %w (is this a valid pack repo?)", err)
+	}
+	return ParseManifest(data, dir)
+}
+
+type manifestValidator struct {
+	errs []FieldError
+}
+
+func (v *manifestValidator) add(field, format string, args ...any) {
+	v.errs = append(v.errs, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkShape rejects unknown keys and values of the wrong JSON type.
+func (v *manifestValidator) checkShape(raw map[string]any) {
+	known := make(map[string]string, len(manifestFields))
+	for _, f := range manifestFields {
+		known[f.name] = f.kind
+	}
+	for _, key := range slices.Sorted(maps.Keys(raw)) {
+		if _, ok := known[key]; !ok {
+			v.add(key, "unknown field")
+		}
+	}
+	for _, f := range manifestFields {
+		val, ok := raw[f.name]
+		if !ok {
+			continue
+		}
+		switch f.kind {
+		case "string":
+			if _, ok := val.(string); !ok {
+				v.add(f.name, "must be a string")
+			}
+		case "strings":
+			v.checkStrings(f.name, val)
+		case "map":
+			obj, ok := val.(map[string]any)
+			if !ok {
+				v.add(f.name, "must be an object")
+				continue
+			}
+			for _, key := range slices.Sorted(maps.Keys(obj)) {
+				if _, ok := obj[key].(string); !ok {
+					v.add(f.name+"."+key, "must be a string")
+				}
+			}
+		case "object":
+			obj, ok := val.(map[string]any)
+			if !ok {
+				v.add(f.name, "must be an object")
+				continue
+			}
+			for _, key := range slices.Sorted(maps.Keys(obj)) {
+				if !slices.Contains(contentFields, key) {
+					v.add(f.name+"."+key, "unknown content type (expected one of %s)", strings.Join(contentFields, ", "))
+					continue
+				}
+				v.checkStrings(f.name+"."+key, obj[key])
+			}
+		case "events":
+			obj, ok := val.(map[string]any)
+			if !ok {
+				v.add(f.name, "must be an object")
+				continue
+			}
+			for _, hook := range slices.Sorted(maps.Keys(obj)) {
+				field := f.name + "." + hook
+				ev, ok := obj[hook].(map[string]any)
+				if !ok {
+					v.add(field, "must be an object")
+					continue
+				}
+				for _, key := range slices.Sorted(maps.Keys(ev)) {
+					switch kind := hookEventFields[key]; kind {
+					case "":
+						v.add(field+"."+key, "unknown field (expected event, matcher, or timeout)")
+					case "string":
+						if _, ok := ev[key].(string); !ok {
+							v.add(field+"."+key, "must be a string")
+						}
+					case "number":
+						n, ok := ev[key].(float64)
+						switch {
+						case !ok || n != float64(int(n)):
+							v.add(field+"."+key, "must be a whole number of seconds")
+						case n < 1:
+							// Checked before decoding: an explicit 0 decodes like
+							// an omitted timeout, which means the runtime default.
+							v.add(field+"."+key, "must be between 1 and %d seconds", MaxHookTimeout)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func (v *manifestValidator) checkStrings(field string, val any) {
+	list, ok := val.([]any)
+	if !ok {
+		v.add(field, "must be an array of strings")
+		return
+	}
+	for i, item := range list {
+		if _, ok := item.(string); !ok {
+			v.add(fmt.Sprintf("%s[%d]", field, i), "must be a string")
+		}
+	}
+}
+
+// checkValues validates the formats of the decoded fields.
+func (v *manifestValidator) checkValues(m *PackManifest) {
+	if m.Schema != "" {
+		match := schemaVersion.FindStringSubmatch(m.Schema)
+		switch {
+		case match == nil:
+			v.add("$schema", "%q is not a pack.json schema (expected %s)", m.Schema, ManifestSchemaID)
+		case match[1] != "1":
+			v.add("$schema", "schema version %s is not supported by this plugin (supports v1)", match[1])
+		}
+	}
+
+	switch {
+	case m.Name == "":
+		v.add("name", "is required")
+	case !packNamePattern.MatchString(m.Name):
+		v.add("name", "%q must be owner/slug in lowercase letters, digits, and dashes (e.g. \"orchestra-mcp/pack-go-backend\")", m.Name)
+	}
+
+	switch {
+	case m.Version == "":
+		v.add("version", "is required")
+	case strings.HasPrefix(m.Version, "v"):
+		v.add("version", "%q must be a semantic version without a \"v\" prefix (e.g. %q)", m.Version, strings.TrimPrefix(m.Version, "v"))
+	default:
+		if _, err := ParseVersion(m.Version); err != nil {
+			v.add("version", "%q is not a semantic version (expected MAJOR.MINOR.PATCH, e.g. \"1.0.0\")", m.Version)
+		}
+	}
+
+	switch m.Type {
+	case "", TypePack:
+	case TypeBundle:
+		if len(m.Requires) == 0 {
+			v.add("requires", "a bundle must require at least one pack")
+		}
+	default:
+		v.add("type", "unknown pack type %q (expected %q or %q)", m.Type, TypePack, TypeBundle)
+	}
+
+	for i, req := range m.Requires {
+		field := fmt.Sprintf("requires[%d]", i)
+		switch {
+		case !packNamePattern.MatchString(req):
+			v.add(field, "%q must be a pack name (owner/slug)", req)
+		case req == m.Name:
+			v.add(field, "a pack cannot require itself")
+		}
+	}
+	v.checkNonEmpty("stacks", m.Stacks)
+	v.checkNonEmpty("tags", m.Tags)
+
+	v.checkNames("contents.skills", m.Contents.Skills, slugPattern, "a lowercase slug (e.g. \"go-backend\")")
+	v.checkNames("contents.agents", m.Contents.Agents, slugPattern, "a lowercase slug (e.g. \"go-architect\")")
+	v.checkNames("contents.hooks", m.Contents.Hooks, slugPattern, "a lowercase slug without the .sh extension")
+	v.checkNames("contents.workflows", m.Contents.Workflows, workflowPattern, "a lowercase .yaml file name (e.g. \"feature.yaml\")")
+
+	for _, hook := range slices.Sorted(maps.Keys(m.HookEvents)) {
+		field := "hook_events." + hook
+		ev := m.HookEvents[hook]
+		if !slices.Contains(m.Contents.Hooks, hook) {
+			v.add(field, "%q is not listed in contents.hooks", hook)
+		}
+		switch {
+		case ev.Event == "":
+			v.add(field+".event", "is required")
+		case !slices.Contains(KnownHookEvents, ev.Event):
+			v.add(field+".event", "unknown event %q (expected one of %s)", ev.Event, strings.Join(KnownHookEvents, ", "))
+		}
+		if _, err := regexp.Compile(ev.Matcher); err != nil {
+			v.add(field+".matcher", "%q is not a valid regular expression", ev.Matcher)
+		}
+		if ev.Timeout > MaxHookTimeout {
+			v.add(field+".timeout", "must be between 1 and %d seconds", MaxHookTimeout)
+		}
+	}
+
+	if m.Changelog != "" && !filepath.IsLocal(m.Changelog) {
+		v.add("changelog", "%q must be a relative path inside the pack", m.Changelog)
+	}
+
+	for _, rel := range slices.Sorted(maps.Keys(m.Digests)) {
+		field := "digests." + rel
+		top, _, _ := strings.Cut(rel, "/")
+		switch {
+		case !filepath.IsLocal(rel) || strings.Contains(rel, "\\") || !slices.Contains(contentDirs, top):
+			v.add(field, "must be a slash-separated path under skills/, agents/, hooks/, or workflow/")
+		case !digestPattern.MatchString(m.Digests[rel]):
+			v.add(field, "%q must be \"sha256:\" followed by 64 lowercase hex digits", m.Digests[rel])
+		}
+	}
+}
+
+func (v *manifestValidator) checkNonEmpty(field string, list []string) {
+	for i, s := range list {
+		if strings.TrimSpace(s) == "" {
+			v.add(fmt.Sprintf("%s[%d]", field, i), "must not be empty")
+		}
+	}
+}
+
+func (v *manifestValidator) checkNames(field string, names []string, pattern *regexp.Regexp, want string) {
+	seen := make(map[string]int, len(names))
+	for i, name := range names {
+		item := fmt.Sprintf("%s[%d]", field, i)
+		if !pattern.MatchString(name) {
+			v.add(item, "%q must be %s", name, want)
+		}
+		if j, dup := seen[name]; dup {
+			v.add(item, "%q is already listed at %s[%d]", name, field, j)
+		}
+		seen[name] = i
+	}
+}
+
+// checkFiles verifies declared contents exist in dir and that the content
+// directories hold nothing undeclared. Dotfiles such as .gitkeep are ignored.
+func (v *manifestValidator) checkFiles(m *PackManifest, dir string) {
+	for i, name := range m.Contents.Skills {
+		if !isFile(filepath.Join(dir, "skills", name, "SKILL.md")) {
+			v.add(fmt.Sprintf("contents.skills[%d]", i), "skills/%s/SKILL.md does not exist", name)
+		}
+	}
+	for i, name := range m.Contents.Agents {
+		if !isFile(filepath.Join(dir, "agents", name+".md")) {
+			v.add(fmt.Sprintf("contents.agents[%d]", i), "agents/%s.md does not exist", name)
+		}
+	}
+	for i, name := range m.Contents.Hooks {
+		if !isFile(filepath.Join(dir, "hooks", name+".sh")) {
+			v.add(fmt.Sprintf("contents.hooks[%d]", i), "hooks/%s.sh does not exist", name)
+		}
+	}
+	for i, name := range m.Contents.Workflows {
+		if !isFile(filepath.Join(dir, "workflow", name)) {
+			v.add(fmt.Sprintf("contents.workflows[%d]", i), "workflow/%s does not exist", name)
+		}
+	}
+	if m.Changelog != "" && filepath.IsLocal(m.Changelog) && !isFile(filepath.Join(dir, m.Changelog)) {
+		v.add("changelog", "%s does not exist", m.Changelog)
+	}
+
+	v.checkStray(dir, "skills", "contents.skills", m.Contents.Skills, func(e os.DirEntry) string {
+		if e.IsDir() {
+			return e.Name()
+		}
+		return ""
+	})
+	v.checkStray(dir, "agents", "contents.agents", m.Contents.Agents, func(e os.DirEntry) string {
+		name, ok := strings.CutSuffix(e.Name(), ".md")
+		if !ok || e.IsDir() {
+			return ""
+		}
+		return name
+	})
+	v.checkStray(dir, "hooks", "contents.hooks", m.Contents.Hooks, func(e os.DirEntry) string {
+		name, ok := strings.CutSuffix(e.Name(), ".sh")
+		if !ok || e.IsDir() {
+			return ""
+		}
+		return name
+	})
+	v.checkStray(dir, "workflow", "contents.workflows", m.Contents.Workflows, func(e os.DirEntry) string {
+		if e.IsDir() {
+			return ""
+		}
+		return e.Name()
+	})
+}
+
+// checkStray reports entries of dir/sub that are not declared in the
+// manifest. name maps a directory entry to its content name, or "" if the
+// entry can never be valid content there.
+func (v *manifestValidator) checkStray(dir, sub, field string, declared []string, name func(os.DirEntry) string) {
+	entries, err := os.ReadDir(filepath.Join(dir, sub))
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		n := name(e)
+		switch {
+		case n == "":
+			v.add(field, "%s/%s is not a recognised content file", sub, e.Name())
+		case !slices.Contains(declared, n):
+			v.add(field, "%s/%s exists but %q is not declared", sub, e.Name(), n)
+		}
+	}
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// lineCol converts a byte offset into a 1-based line and column.
+func lineCol(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := int(offset) - bytes.LastIndexByte(before, '\n')
+	return line, col
+}
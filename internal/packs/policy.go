@@ -0,0 +1,244 @@
+package packs
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PolicyFile is the name of an install policy file. A workspace keeps one in
+// its .claude directory so it is committed with the repo; a machine-wide one
+// lives in ~/.orchestra.
+const PolicyFile = "marketplace-policy.json"
+
+// Policy restricts what pack operations may install.
+type Policy struct {
+	// Path is the file the policy was loaded from.
+	Path string `json:"-"`
+
+	// AllowedSources lists the repos packs may come from. Entries are repo
+	// paths or prefixes ("github.com/acme"), or path.Match patterns
+	// ("github.com/acme/pack-*"); "*" allows any. Empty allows any source
+	// that is not blocked.
+	AllowedSources []string `json:"allowed_sources,omitempty"`
+	// BlockedSources lists repos that are never installed, in the same
+	// format. They win over AllowedSources.
+	BlockedSources []string `json:"blocked_sources,omitempty"`
+	// RequireSignature requires every pack to be signed by a trusted key.
+	RequireSignature bool `json:"require_signature,omitempty"`
+	// AllowedLicenses lists the SPDX licenses packs may declare. A license
+	// expression is allowed if it can be complied with using only these (see
+	// LicenseExpr.Satisfies). Empty allows any, including none.
+	AllowedLicenses []string `json:"allowed_licenses,omitempty"`
+	// MaxHookRisk is the highest hook risk level (see ScanHook) a pack may
+	// install. Empty allows any.
+	MaxHookRisk string `json:"max_hook_risk,omitempty"`
+	// ForbiddenContent lists content types ("skills", "agents", "hooks",
+	// "workflows") packs may not install.
+	ForbiddenContent []string `json:"forbidden_content,omitempty"`
+}
+
+// Policies are the policy files in effect. An operation must satisfy each of
+// them, so the global policy cannot be loosened by a workspace one.
+type Policies []*Policy
+
+// PolicyViolation is returned when an operation breaks a policy rule.
+type PolicyViolation struct {
+	Path   string
+	Rule   string
+	Detail string
+}
+
+func (v *PolicyViolation) Error() string {
+	return fmt.Sprintf("blocked by policy rule %s in %s: %s", v.Rule, v.Path, v.Detail)
+}
+
+// GlobalPolicyPath returns the machine-wide policy file, or "" if the home
+// directory is unknown.
+func GlobalPolicyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".orchestra", PolicyFile)
+}
+
+// WorkspacePolicyPath returns the policy file of a workspace.
+func WorkspacePolicyPath(workspace string) string {
+	return filepath.Join(workspace, ".claude", PolicyFile)
+}
+
+// LoadPolicies reads the global and workspace policies. Missing files are
+// skipped; invalid ones are an error, so a typo never disables a policy.
+func LoadPolicies(workspace string) (Policies, error) {
+	var policies Policies
+	for _, p := range []string{GlobalPolicyPath(), WorkspacePolicyPath(workspace)} {
+		if p == "" {
+			continue
+		}
+		policy, err := ReadPolicy(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// contentTypes are the values accepted in ForbiddenContent.
+var contentTypes = []string{ContentSkills, ContentAgents, ContentHooks, ContentWorkflows}
+
+// ReadPolicy reads and validates one policy file.
+func ReadPolicy(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	p := &Policy{Path: file}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	if p.MaxHookRisk != "" {
+		if _, err := ParseRiskLevel(p.MaxHookRisk); err != nil {
+			return nil, fmt.Errorf("%s: max_hook_risk: %w", file, err)
+		}
+	}
+	for _, c := range p.ForbiddenContent {
+		if !slices.Contains(contentTypes, c) {
+			return nil, fmt.Errorf("%s: forbidden_content: unknown content type %q (want one of %s)", file, c, strings.Join(contentTypes, ", "))
+		}
+	}
+	for _, pattern := range slices.Concat(p.AllowedSources, p.BlockedSources) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: invalid source pattern %q", file, pattern)
+		}
+	}
+	return p, nil
+}
+
+func (p *Policy) violation(rule, format string, args ...any) error {
+	return &PolicyViolation{Path: p.Path, Rule: rule, Detail: fmt.Sprintf(format, args...)}
+}
+
+// CheckSource checks a repo against every policy. It runs before anything is
+// fetched.
+func (ps Policies) CheckSource(repo string) error {
+	for _, p := range ps {
+		if pattern, ok := matchSource(p.BlockedSources, repo); ok {
+			return p.violation("blocked_sources", "%s matches blocked source %q", repo, pattern)
+		}
+		if len(p.AllowedSources) > 0 {
+			if _, ok := matchSource(p.AllowedSources, repo); !ok {
+				return p.violation("allowed_sources", "%s is not an allowed source (allowed: %s)", repo, strings.Join(p.AllowedSources, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// CheckManifest checks a fetched pack: the sources of the packs it
+// requires, which a bundle exists to install, its signature, license, and
+// content types. signer is the key ID that verified the pack, if any.
+func (ps Policies) CheckManifest(m *PackManifest, signer string) error {
+	for _, req := range m.Requires {
+		if err := ps.CheckSource(ResolvePackRepo(req)); err != nil {
+			var v *PolicyViolation
+			if errors.As(err, &v) {
+				v.Detail = fmt.Sprintf("required pack %s: %s", req, v.Detail)
+			}
+			return err
+		}
+	}
+	for _, p := range ps {
+		if p.RequireSignature && signer == "" {
+			return p.violation("require_signature", "%s is not signed by a trusted key; add the publisher's key with set_trusted_keys", m.Name)
+		}
+		if len(p.AllowedLicenses) > 0 && !LicenseAllowed(m.License, p.AllowedLicenses) {
+			return p.violation("allowed_licenses", "%s has license %q (allowed: %s)", m.Name, cmp.Or(m.License, "none"), strings.Join(p.AllowedLicenses, ", "))
+		}
+		for _, c := range p.ForbiddenContent {
+			if n := len(contentList(m.Contents, c)); n > 0 {
+				return p.violation("forbidden_content", "%s contains %d %s", m.Name, n, c)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckHooks rejects hooks whose risk level exceeds a policy's MaxHookRisk.
+func (ps Policies) CheckHooks(scans map[string]*HookScan) error {
+	for _, p := range ps {
+		if p.MaxHookRisk == "" {
+			continue
+		}
+		limit, err := ParseRiskLevel(p.MaxHookRisk)
+		if err != nil {
+			return err
+		}
+		var over []string
+		for _, name := range slices.Sorted(maps.Keys(scans)) {
+			if scan := scans[name]; scan.Level > limit {
+				over = append(over, fmt.Sprintf("%s is %s", name, scan.Summary()))
+			}
+		}
+		if len(over) > 0 {
+			return p.violation("max_hook_risk", "hooks above %s risk: %s", limit, strings.Join(over, "; "))
+		}
+	}
+	return nil
+}
+
+// MaxHookRisk returns the strictest hook risk limit, or RiskCritical when no
+// policy sets one.
+func (ps Policies) MaxHookRisk() (RiskLevel, bool) {
+	limit, set := RiskCritical, false
+	for _, p := range ps {
+		if level, err := ParseRiskLevel(p.MaxHookRisk); p.MaxHookRisk != "" && err == nil {
+			limit, set = min(limit, level), true
+		}
+	}
+	return limit, set
+}
+
+// matchSource returns the first pattern that matches repo.
+func matchSource(patterns []string, repo string) (string, bool) {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(pattern, "/")
+		if pattern == "*" || repo == pattern || strings.HasPrefix(repo, pattern+"/") {
+			return pattern, true
+		}
+		if ok, _ := path.Match(pattern, repo); ok {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// contentList returns the names of one content type.
+func contentList(c PackContents, contentType string) []string {
+	switch contentType {
+	case ContentSkills:
+		return c.Skills
+	case ContentAgents:
+		return c.Agents
+	case ContentHooks:
+		return c.Hooks
+	case ContentWorkflows:
+		return c.Workflows
+	}
+	return nil
+}
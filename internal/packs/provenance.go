@@ -0,0 +1,162 @@
+package packs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProvenanceFile is the file under .claude/ that records which pack
+// installed each skill, agent, hook, and workflow, so the workspace's
+// content says where it came from without the registry.
+const ProvenanceFile = ".provenance.json"
+
+// Provenance is where an installed skill, agent, hook, or workflow came
+// from.
+type Provenance struct {
+	Pack    string `json:"pack"`
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Repo    string `json:"repo,omitempty"`
+	// Path is the item's file in the pack: skills/<slug>/SKILL.md,
+	// agents/<slug>.md, hooks/<slug>.sh, or workflow/<file>.
+	Path string `json:"path"`
+	// Modified reports whether the workspace files differ from what the
+	// pack installed. It goes stale as files are edited, so it is checked
+	// against the registry's digests rather than read from ProvenanceFile.
+	Modified bool `json:"-"`
+}
+
+// SourcePath returns the path of an item's main file in its pack. For a
+// workflow, item is the file name the pack lists.
+func SourcePath(kind, item string) string {
+	switch kind {
+	case ContentSkills:
+		return "skills/" + item + "/SKILL.md"
+	case ContentAgents:
+		return "agents/" + item + ".md"
+	case ContentHooks:
+		return "hooks/" + item + ".sh"
+	case ContentWorkflows:
+		return "workflow/" + item
+	}
+	return kind + "/" + item
+}
+
+// ContentModified reports whether any file of an installed item is missing
+// or differs from the digest recorded when its pack was installed. digests
+// is keyed by path relative to .claude, as in the registry; an item without
+// recorded digests is reported unmodified.
+func ContentModified(workspace, kind, item string, digests map[string]string) bool {
+	main := InstalledPath(SourcePath(kind, item))
+	own := make(map[string]string)
+	for rel, d := range digests {
+		if rel == main || (kind == ContentSkills && strings.HasPrefix(rel, "skills/"+item+"/")) {
+			own[rel] = d
+		}
+	}
+	for _, f := range VerifyInstalled(workspace, own) {
+		if f.Status != FileOK {
+			return true
+		}
+	}
+	return false
+}
+
+// Tags returns the storage-only fields that carry provenance on a storage
+// entry, for ApplyTags. A nil Provenance clears them.
+func (p *Provenance) Tags() map[string]any {
+	tags := map[string]any{"pack": nil, "pack_version": nil, "pack_commit": nil, "source_path": nil, "modified": nil}
+	if p == nil {
+		return tags
+	}
+	tags["pack"] = p.Pack
+	tags["pack_version"] = p.Version
+	tags["source_path"] = p.Path
+	tags["modified"] = p.Modified
+	if p.Commit != "" {
+		tags["pack_commit"] = p.Commit
+	}
+	return tags
+}
+
+// ProvenanceFromMetadata reads provenance back from a storage entry's
+// metadata, or returns nil if the entry has no pack.
+func ProvenanceFromMetadata(meta map[string]any) *Provenance {
+	pack, _ := meta["pack"].(string)
+	if pack == "" {
+		return nil
+	}
+	p := &Provenance{Pack: pack}
+	p.Version, _ = meta["pack_version"].(string)
+	p.Commit, _ = meta["pack_commit"].(string)
+	p.Path, _ = meta["source_path"].(string)
+	p.Modified, _ = meta["modified"].(bool)
+	return p
+}
+
+// String renders provenance for tool output, e.g. "pack `go` v1.2.0
+// (a1b2c3d), from skills/go/SKILL.md, modified locally".
+func (p *Provenance) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "pack `%s`", p.Pack)
+	if p.Version != "" {
+		fmt.Fprintf(&b, " v%s", p.Version)
+	}
+	if p.Commit != "" {
+		fmt.Fprintf(&b, " (%s)", p.Commit[:min(7, len(p.Commit))])
+	}
+	if p.Path != "" {
+		fmt.Fprintf(&b, ", from %s", p.Path)
+	}
+	if p.Modified {
+		b.WriteString(", modified locally")
+	}
+	return b.String()
+}
+
+// WriteProvenance records the provenance of the workspace's installed
+// content in .claude/.provenance.json, keyed by SyncKey. An empty map
+// removes the file.
+func WriteProvenance(workspace string, prov map[string]*Provenance) error {
+	path := filepath.Join(workspace, ".claude", ProvenanceFile)
+	if len(prov) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(prov, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadProvenance reads .claude/.provenance.json. A workspace without one
+// returns an empty map.
+func ReadProvenance(workspace string) (map[string]*Provenance, error) {
+	prov := make(map[string]*Provenance)
+	data, err := os.ReadFile(filepath.Join(workspace, ".claude", ProvenanceFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return prov, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &prov); err != nil {
+		return nil, fmt.Errorf("%s: %w", ProvenanceFile, err)
+	}
+	return prov, nil
+}
@@ -0,0 +1,273 @@
+package packs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Outdated statuses reported by CheckOutdated.
+const (
+	StatusCurrent     = "current"     // installed commit is the wanted one and nothing newer exists
+	StatusOutdated    = "outdated"    // a newer release satisfies the constraint
+	StatusConstrained = "constrained" // up to date within the constraint, newer releases exist outside it
+	StatusNoMatch     = "no-match"    // no release satisfies the constraint
+	StatusUnknown     = "unknown"     // the source could not be queried
+)
+
+// RemoteRefs is the state of a pack repo as reported by git ls-remote.
+type RemoteRefs struct {
+	Head     string            // commit of the default branch
+	Tags     map[string]string // tag name -> commit (annotated tags peeled)
+	Branches map[string]string // branch name -> commit
+}
+
+// ListRemote queries a pack repo's branches and tags without cloning it.
+func ListRemote(ctx context.Context, repo string) (*RemoteRefs, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", cloneURL(repo))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote %s: %s", repo, strings.TrimSpace(stderr.String()))
+	}
+	return parseLsRemote(out), nil
+}
+
+// parseLsRemote parses "<commit>\t<ref>" lines. Peeled "^{}" entries replace
+// the tag object hash with the commit it points to.
+func parseLsRemote(out []byte) *RemoteRefs {
+	refs := &RemoteRefs{Tags: map[string]string{}, Branches: map[string]string{}}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		commit, ref, ok := strings.Cut(sc.Text(), "\t")
+		if !ok {
+			continue
+		}
+		switch {
+		case ref == "HEAD":
+			refs.Head = commit
+		case strings.HasPrefix(ref, "refs/heads/"):
+			refs.Branches[strings.TrimPrefix(ref, "refs/heads/")] = commit
+		case strings.HasPrefix(ref, "refs/tags/"):
+			tag := strings.TrimPrefix(ref, "refs/tags/")
+			if peeled, ok := strings.CutSuffix(tag, "^{}"); ok {
+				refs.Tags[peeled] = commit
+			} else if _, seen := refs.Tags[tag]; !seen {
+				refs.Tags[tag] = commit
+			}
+		}
+	}
+	return refs
+}
+
+// Best returns the highest semver tag allowed by c, or false if none is.
+// Tags that are not semantic versions are ignored.
+func (r *RemoteRefs) Best(c *Constraint) (string, Version, bool) {
+	var (
+		bestTag string
+		best    Version
+		found   bool
+	)
+	for tag := range r.Tags {
+		v, err := ParseVersion(tag)
+		if err != nil || !c.Allows(v) {
+			continue
+		}
+		// Prefer the "v"-prefixed spelling when both exist, for stable output.
+		if !found || v.Compare(best) > 0 || (v.Compare(best) == 0 && tag > bestTag) {
+			bestTag, best, found = tag, v, true
+		}
+	}
+	return bestTag, best, found
+}
+
+// Latest returns the highest release tag, ignoring prereleases.
+func (r *RemoteRefs) Latest() (string, Version, bool) {
+	all, _ := ParseConstraint("")
+	return r.Best(all)
+}
+
+// isConstraint reports whether a requested version should be resolved against
+// the repo's tags rather than passed to git as a literal branch or tag.
+func isConstraint(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := ParseConstraint(s)
+	return err == nil
+}
+
+// ResolveRef turns a requested version into the git ref to clone. Semver
+// constraints ("^1.2", "~0.3.1", "1.4.0") resolve to the highest matching
+// tag; anything else (a branch name, a non-semver tag) is returned unchanged.
+// An empty request means the default branch.
+func ResolveRef(ctx context.Context, repo, requested string) (string, error) {
+	if !isConstraint(requested) {
+		return requested, nil
+	}
+	refs, err := ListRemote(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+	return resolveRef(refs, repo, requested)
+}
+
+// resolveRef is ResolveRef against refs already listed from repo.
+func resolveRef(refs *RemoteRefs, repo, requested string) (string, error) {
+	if !isConstraint(requested) {
+		return requested, nil
+	}
+	c, _ := ParseConstraint(requested)
+	tag, _, ok := refs.Best(c)
+	if !ok {
+		if _, literal := refs.Tags[requested]; literal {
+			return requested, nil
+		}
+		return "", fmt.Errorf("no release of %s satisfies %q", repo, requested)
+	}
+	return tag, nil
+}
+
+// OutdatedQuery describes one installed pack to check.
+type OutdatedQuery struct {
+	Name       string
+	Repo       string
+	Version    string // installed manifest version
+	Commit     string // installed commit, if recorded
+	Constraint string // version requested at install time, empty for latest
+}
+
+// OutdatedReport compares an installed pack with its source.
+type OutdatedReport struct {
+	OutdatedQuery
+	Wanted       string // highest version allowed by the constraint; with none, the default branch
+	WantedCommit string
+	WantedRef    string // git ref update_pack clones for Wanted, empty for the default branch
+	Latest       string // highest release overall
+	LatestCommit string
+	Status       string
+	Err          error
+}
+
+// CheckOutdated queries each pack's source with at most workers concurrent
+// ls-remote calls. Reports are returned in the same order as queries.
+func CheckOutdated(ctx context.Context, queries []OutdatedQuery, workers int) []OutdatedReport {
+	return checkOutdated(ctx, queries, workers, ListRemote)
+}
+
+func checkOutdated(ctx context.Context, queries []OutdatedQuery, workers int, list func(context.Context, string) (*RemoteRefs, error)) []OutdatedReport {
+	reports := make([]OutdatedReport, len(queries))
+	parallel(len(queries), workers, func(i int) {
+		q := queries[i]
+		refs, err := list(ctx, q.Repo)
+		if err != nil {
+			reports[i] = OutdatedReport{OutdatedQuery: q, Status: StatusUnknown, Err: err}
+			return
+		}
+		reports[i] = compareRemote(q, refs)
+	})
+	return reports
+}
+
+// compareRemote works out the wanted and latest versions of one pack and how
+// the installed version relates to them. A pack installed without a version
+// tracks the default branch, as update_pack does, so wanted is its head:
+// named by its tag when the head is tagged, and by commit otherwise. Repos
+// without semver tags report the head as latest too.
+func compareRemote(q OutdatedQuery, refs *RemoteRefs) OutdatedReport {
+	r := OutdatedReport{OutdatedQuery: q}
+
+	if tag, _, ok := refs.Latest(); ok {
+		r.Latest, r.LatestCommit = tag, refs.Tags[tag]
+	} else {
+		r.Latest, r.LatestCommit = ShortCommit(refs.Head), refs.Head
+	}
+
+	switch {
+	case q.Constraint == "":
+		r.Wanted, r.WantedCommit = headName(refs), refs.Head
+	case isConstraint(q.Constraint):
+		c, _ := ParseConstraint(q.Constraint)
+		if tag, _, ok := refs.Best(c); ok {
+			r.Wanted, r.WantedCommit = tag, refs.Tags[tag]
+		} else if commit, ok := refs.Tags[q.Constraint]; ok {
+			r.Wanted, r.WantedCommit = q.Constraint, commit
+		}
+	default:
+		// Literal branch or tag: wanted is wherever that ref points now.
+		if commit, ok := refs.Branches[q.Constraint]; ok {
+			r.Wanted, r.WantedCommit = q.Constraint, commit
+		} else if commit, ok := refs.Tags[q.Constraint]; ok {
+			r.Wanted, r.WantedCommit = q.Constraint, commit
+		}
+	}
+	// Resolved the way update_pack's fetch resolves it, so release notes
+	// describe what an update would install.
+	r.WantedRef, _ = resolveRef(refs, q.Repo, q.Constraint)
+
+	switch {
+	case r.WantedCommit == "":
+		r.Status = StatusNoMatch
+	case !atVersion(q, r.Wanted, r.WantedCommit):
+		r.Status = StatusOutdated
+	case r.LatestCommit != r.WantedCommit && newer(r.Latest, r.Wanted):
+		r.Status = StatusConstrained
+	default:
+		r.Status = StatusCurrent
+	}
+	return r
+}
+
+// headName names the default branch head by its highest semver tag, or by
+// its short commit when no release points at it.
+func headName(refs *RemoteRefs) string {
+	var name string
+	var best Version
+	for tag, commit := range refs.Tags {
+		v, err := ParseVersion(tag)
+		if err != nil || commit != refs.Head {
+			continue
+		}
+		if name == "" || v.Compare(best) > 0 || (v.Compare(best) == 0 && tag > name) {
+			name, best = tag, v
+		}
+	}
+	if name == "" {
+		return ShortCommit(refs.Head)
+	}
+	return name
+}
+
+// atVersion reports whether the installed pack is at the given ref. Commits
+// are compared when recorded; older registry entries fall back to versions.
+func atVersion(q OutdatedQuery, tag, commit string) bool {
+	if q.Commit != "" {
+		return q.Commit == commit
+	}
+	installed, err1 := ParseVersion(q.Version)
+	target, err2 := ParseVersion(tag)
+	return err1 == nil && err2 == nil && installed.Compare(target) == 0
+}
+
+// newer reports whether tag a is a higher version than tag b. Refs that are
+// not semantic versions (commit hashes, branches) are never ordered.
+func newer(a, b string) bool {
+	va, err1 := ParseVersion(a)
+	vb, err2 := ParseVersion(b)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return va.Compare(vb) > 0
+}
+
+// ShortCommit abbreviates a commit hash for display.
+func ShortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -0,0 +1,233 @@
+package packs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Limits on the content a pack may install, so a hostile pack cannot fill the
+// workspace. lint_pack reports files over MaxPackFileSize as errors too.
+const (
+	MaxPackFiles    = 1000     // content files per pack
+	MaxPackFileSize = 1 << 20  // bytes per content file
+	MaxPackSize     = 16 << 20 // bytes of content per pack
+)
+
+// UnsafeFile is one pack file that cannot be installed safely.
+type UnsafeFile struct {
+	Path   string // pack-relative, slash-separated
+	Reason string
+	// Oversize is set when the only problem is a file over MaxPackFileSize.
+	Oversize bool
+}
+
+func (u UnsafeFile) String() string {
+	return u.Path + ": " + u.Reason
+}
+
+// UnsafePackError reports pack content that would escape the workspace
+// .claude directory or exceed the pack limits.
+type UnsafePackError struct {
+	Repo  string
+	Files []UnsafeFile
+}
+
+func (e *UnsafePackError) Error() string {
+	problems := make([]string, len(e.Files))
+	for i, f := range e.Files {
+		problems[i] = f.String()
+	}
+	return fmt.Sprintf("unsafe pack %s: %s", e.Repo, strings.Join(problems, "; "))
+}
+
+// CheckPackFiles checks the files a pack installs before anything is copied.
+// Declared content and pack.json must be regular files inside the pack. A
+// skill may contain relative symlinks to files in the same skill, which are
+// installed as links; any other symlink or special file is rejected. File
+// count and sizes are bounded by the pack limits.
+func CheckPackFiles(dir string, m *PackManifest) []UnsafeFile {
+	c := &packChecker{dir: dir}
+	c.regular("pack.json")
+	if m.Changelog != "" {
+		c.regular(m.Changelog)
+	}
+	for _, sub := range contentDirs {
+		if info, err := os.Lstat(filepath.Join(dir, sub)); err == nil && !info.IsDir() {
+			c.unsafe(sub, "content directory is not a directory")
+		}
+	}
+	for _, name := range m.Contents.Skills {
+		c.skill("skills/" + name)
+	}
+	for _, name := range m.Contents.Agents {
+		c.regular("agents/" + name + ".md")
+	}
+	for _, name := range m.Contents.Hooks {
+		c.regular("hooks/" + name + ".sh")
+	}
+	for _, name := range m.Contents.Workflows {
+		c.regular("workflow/" + name)
+	}
+	if c.files > MaxPackFiles {
+		c.unsafe(".", fmt.Sprintf("pack has more than %d content files", MaxPackFiles))
+	}
+	if c.size > MaxPackSize {
+		c.unsafe(".", fmt.Sprintf("pack content is %s (limit %s)", FormatSize(c.size), FormatSize(MaxPackSize)))
+	}
+	return c.problems
+}
+
+type packChecker struct {
+	dir      string
+	files    int
+	size     int64
+	problems []UnsafeFile
+}
+
+func (c *packChecker) unsafe(rel, reason string) {
+	c.problems = append(c.problems, UnsafeFile{Path: rel, Reason: reason})
+}
+
+// count adds a content file to the totals and checks its size.
+func (c *packChecker) count(rel string, size int64) {
+	c.files++
+	c.size += size
+	if size > MaxPackFileSize {
+		c.problems = append(c.problems, UnsafeFile{
+			Path:     rel,
+			Reason:   fmt.Sprintf("file is %s (limit %s)", FormatSize(size), FormatSize(MaxPackFileSize)),
+			Oversize: true,
+		})
+	}
+}
+
+// regular checks a file that must not be a link. A missing file is left to
+// manifest validation.
+func (c *packChecker) regular(rel string) {
+	info, err := os.Lstat(filepath.Join(c.dir, filepath.FromSlash(rel)))
+	switch {
+	case err != nil:
+	case info.Mode()&fs.ModeSymlink != 0:
+		c.unsafe(rel, "is a symlink")
+	case !info.Mode().IsRegular():
+		c.unsafe(rel, "is not a regular file")
+	default:
+		c.count(rel, info.Size())
+	}
+}
+
+// skill checks every file of a skill directory.
+func (c *packChecker) skill(rel string) {
+	root := filepath.Join(c.dir, filepath.FromSlash(rel))
+	if info, err := os.Lstat(root); err != nil || !info.IsDir() {
+		if err == nil {
+			c.unsafe(rel, "is not a directory")
+		}
+		return
+	}
+	filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		inSkill, _ := filepath.Rel(root, p)
+		name := rel + "/" + filepath.ToSlash(inSkill)
+		switch {
+		case c.files > MaxPackFiles:
+			return fs.SkipAll
+		case d.IsDir():
+			return nil
+		case d.Type()&fs.ModeSymlink != 0:
+			if reason := skillLinkProblem(root, inSkill); reason != "" {
+				c.unsafe(name, reason)
+			} else {
+				c.count(name, 0)
+			}
+		case !d.Type().IsRegular():
+			c.unsafe(name, "is not a regular file")
+		default:
+			if info, err := d.Info(); err == nil {
+				c.count(name, info.Size())
+			}
+		}
+		return nil
+	})
+}
+
+// skillLinkProblem checks a symlink inside a skill directory. It must be
+// relative and resolve to a regular file in the same skill.
+func skillLinkProblem(root, inSkill string) string {
+	target, err := os.Readlink(filepath.Join(root, inSkill))
+	if err != nil {
+		return err.Error()
+	}
+	if filepath.IsAbs(target) || !filepath.IsLocal(filepath.Join(filepath.Dir(inSkill), target)) {
+		return fmt.Sprintf("symlink to %s points outside the skill", target)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, inSkill))
+	if err != nil {
+		return fmt.Sprintf("symlink to %s is broken", target)
+	}
+	if rootReal, err := filepath.EvalSymlinks(root); err != nil || !within(rootReal, resolved) {
+		return fmt.Sprintf("symlink to %s points outside the skill", target)
+	}
+	if info, err := os.Stat(resolved); err != nil || !info.Mode().IsRegular() {
+		return fmt.Sprintf("symlink to %s is not a file", target)
+	}
+	return ""
+}
+
+// WorkspacePath resolves a path given relative to the workspace, as tools
+// that write reports take it, rejecting absolute paths and any path that
+// leaves the workspace by ".." or through a symlink.
+func WorkspacePath(workspace, rel string) (string, error) {
+	if filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
+		return "", fmt.Errorf("%s must be relative to the workspace", rel)
+	}
+	return confinedPath(workspace, rel)
+}
+
+// confinedPath joins elem onto root and checks that the result, with any
+// symlinks already on disk resolved, stays inside root. Apply uses it for
+// every destination so neither a crafted name nor a symlink planted in the
+// workspace can redirect a write outside .claude.
+func confinedPath(root string, elem ...string) (string, error) {
+	joined := filepath.Join(append([]string{root}, elem...)...)
+	rel, err := filepath.Rel(root, joined)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("%s escapes %s", filepath.Join(elem...), root)
+	}
+	rootReal, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	// Resolve the deepest part of the path that already exists.
+	existing := joined
+	for {
+		if _, err := os.Lstat(existing); err == nil || existing == root {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if errors.Is(err, fs.ErrNotExist) && existing == joined {
+		// A dangling link at the destination is replaced, not followed.
+		real, err = filepath.EvalSymlinks(filepath.Dir(existing))
+	}
+	if err != nil {
+		return "", err
+	}
+	if !within(rootReal, real) {
+		return "", fmt.Errorf("%s resolves outside %s", filepath.Join(elem...), root)
+	}
+	return joined, nil
+}
+
+// within reports whether path is dir or inside it. Both must be clean.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && (rel == "." || filepath.IsLocal(rel))
+}
@@ -0,0 +1,262 @@
+package packs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Content types that can be included in a scaffolded pack.
+const (
+	ContentSkills    = "skills"
+	ContentAgents    = "agents"
+	ContentHooks     = "hooks"
+	ContentWorkflows = "workflows"
+)
+
+// ScaffoldOptions describes a new pack to generate.
+type ScaffoldOptions struct {
+	Name        string   // owner/slug pack name, e.g. "acme/pack-go-tools"
+	Description string   // defaults to a placeholder
+	License     string   // SPDX identifier, optional
+	Stacks      []string // technology stacks the pack targets
+	Tags        []string // search tags
+	Include     []string // content types; defaults to skills and agents
+}
+
+// ScaffoldPack writes a new pack into dir, which must not exist or be empty.
+// It generates pack.json, one example of each included content type named
+// after the pack, a README, and a CHANGELOG. It returns the manifest and the
+// pack-relative paths of the files written.
+func ScaffoldPack(dir string, opts ScaffoldOptions) (*PackManifest, []string, error) {
+	if !packNamePattern.MatchString(opts.Name) {
+		return nil, nil, fmt.Errorf("name %q must be owner/slug in lowercase letters, digits, and dashes (e.g. \"acme/pack-go-tools\")", opts.Name)
+	}
+	include := opts.Include
+	if len(include) == 0 {
+		include = []string{ContentSkills, ContentAgents}
+	}
+	for _, c := range include {
+		if !slices.Contains(contentFields, c) {
+			return nil, nil, fmt.Errorf("unknown content type %q (expected one of %s)", c, strings.Join(contentFields, ", "))
+		}
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, nil, fmt.Errorf("%s already exists and is not empty", dir)
+	}
+
+	slug := contentSlug(opts.Name)
+	title := toTitleCase(strings.ReplaceAll(slug, "-", " "))
+	desc := opts.Description
+	if desc == "" {
+		desc = fmt.Sprintf("%s skills and agents", title)
+	}
+
+	m := &PackManifest{
+		Schema:      ManifestSchemaID,
+		Name:        opts.Name,
+		Description: desc,
+		Version:     "0.1.0",
+		Type:        TypePack,
+		License:     opts.License,
+		Stacks:      nonNil(opts.Stacks),
+		Tags:        nonNil(opts.Tags),
+		Requires:    []string{},
+		Contents:    PackContents{Skills: []string{}, Agents: []string{}, Hooks: []string{}, Workflows: []string{}},
+		Changelog:   "CHANGELOG.md",
+	}
+	files := map[string]string{}
+	if slices.Contains(include, ContentSkills) {
+		m.Contents.Skills = []string{slug}
+		files[filepath.Join("skills", slug, "SKILL.md")] = skillTemplate(slug, title)
+	}
+	if slices.Contains(include, ContentAgents) {
+		m.Contents.Agents = []string{slug}
+		files[filepath.Join("agents", slug+".md")] = agentTemplate(slug, title)
+	}
+	if slices.Contains(include, ContentHooks) {
+		m.Contents.Hooks = []string{slug}
+		m.HookEvents = map[string]HookEvent{slug: {Event: "PreToolUse", Matcher: "Bash", Timeout: 30}}
+		files[filepath.Join("hooks", slug+".sh")] = hookTemplate(slug)
+		files[filepath.Join("fixtures", "hooks", slug, "allow.json")] = hookFixtureTemplate()
+	}
+	if slices.Contains(include, ContentWorkflows) {
+		m.Contents.Workflows = []string{slug + ".yaml"}
+		files[filepath.Join("workflow", slug+".yaml")] = workflowTemplate(slug, title)
+	}
+	files["README.md"] = readmeTemplate(m, title)
+	files["CHANGELOG.md"] = changelogTemplate(m.Version)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	files["pack.json"] = string(manifest) + "\n"
+
+	var written []string
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, written, err
+		}
+		mode := os.FileMode(0644)
+		if strings.HasPrefix(rel, "hooks"+string(filepath.Separator)) {
+			mode = 0755
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			return nil, written, err
+		}
+		written = append(written, filepath.ToSlash(rel))
+	}
+	slices.Sort(written)
+	return m, written, nil
+}
+
+// contentSlug derives the example content name from a pack name:
+// "acme/pack-go-tools" becomes "go-tools".
+func contentSlug(name string) string {
+	_, repo, _ := strings.Cut(name, "/")
+	slug := strings.TrimPrefix(repo, "pack-")
+	slug = strings.NewReplacer(".", "-", "_", "-").Replace(slug)
+	if slug == "" {
+		slug = "example"
+	}
+	return slug
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
+
+func skillTemplate(slug, title string) string {
+	return fmt.Sprintf(`---
+name: %s
+description: Use when working on %s. Replace this with when Claude should load the skill and what it covers.
+---
+
+# %s
+
+Describe the conventions, commands, and patterns this skill teaches.
+
+## When to use
+
+- List the tasks or files that should trigger this skill.
+
+## Guidelines
+
+1. Keep instructions short and concrete.
+2. Move long reference material into separate files in this directory and link to them.
+`, slug, title, title)
+}
+
+func agentTemplate(slug, title string) string {
+	return fmt.Sprintf(`---
+name: %s
+description: %s specialist. Replace this with when the agent should be used.
+tools: Read, Grep, Glob
+---
+
+You are a %s specialist.
+
+Describe the agent's responsibilities, the steps it follows, and what it
+should report back when it finishes.
+`, slug, title, title)
+}
+
+func hookTemplate(slug string) string {
+	return fmt.Sprintf(`#!/usr/bin/env bash
+# %s: example hook. Claude Code passes the event as JSON on stdin.
+# Exit 0 to allow the action, or exit 2 and write a reason to stderr to block it.
+set -euo pipefail
+
+payload="$(cat)"
+: "${payload}"
+
+exit 0
+`, slug)
+}
+
+func hookFixtureTemplate() string {
+	return `{
+  "payload": {
+    "tool_input": {"command": "go test ./...", "description": "Run the tests"}
+  },
+  "expect": {"exit_code": 0}
+}
+`
+}
+
+func workflowTemplate(slug, title string) string {
+	return fmt.Sprintf(`name: %s
+description: %s feature lifecycle
+initial_state: todo
+states:
+  todo:
+    label: To Do
+  in-progress:
+    label: In Progress
+    active_work: true
+  in-review:
+    label: In Review
+    active_work: true
+  done:
+    label: Done
+    terminal: true
+transitions:
+  - from: todo
+    to: in-progress
+  - from: in-progress
+    to: in-review
+    gate: code-complete
+  - from: in-review
+    to: in-progress
+  - from: in-review
+    to: done
+gates:
+  code-complete:
+    label: Code Complete
+    required_section: Changes
+`, slug, title)
+}
+
+func readmeTemplate(m *PackManifest, title string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n%s\n\n", title, m.Description)
+	fmt.Fprintf(&b, "## Install\n\n```\ninstall_pack repo=github.com/%s\n```\n\n", m.Name)
+	fmt.Fprintf(&b, "## Contents\n\n")
+	for _, s := range m.Contents.Skills {
+		fmt.Fprintf(&b, "- Skill: `%s`\n", s)
+	}
+	for _, a := range m.Contents.Agents {
+		fmt.Fprintf(&b, "- Agent: `%s`\n", a)
+	}
+	for _, h := range m.Contents.Hooks {
+		fmt.Fprintf(&b, "- Hook: `%s`\n", h)
+	}
+	for _, w := range m.Contents.Workflows {
+		fmt.Fprintf(&b, "- Workflow: `%s`\n", w)
+	}
+	fmt.Fprintf(&b, "\n## Development\n\nRun `tools-marketplace lint .` before publishing, and add an entry to CHANGELOG.md for every release.\n")
+	if len(m.Contents.Hooks) > 0 {
+		fmt.Fprintf(&b, "Lint also runs the hook fixtures in `fixtures/hooks/<hook>/*.json` against each hook.\n")
+	}
+	return b.String()
+}
+
+func changelogTemplate(version string) string {
+	return fmt.Sprintf(`# Changelog
+
+## [Unreleased]
+
+## [%s] - %s
+
+- Initial release
+`, version, time.Now().UTC().Format("2006-01-02"))
+}
@@ -0,0 +1,274 @@
+package packs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed semantic version (MAJOR.MINOR.PATCH[-PRERELEASE]).
+// Build metadata is accepted and ignored.
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 string
+}
+
+// ParseVersion parses a full semantic version, with or without a leading "v".
+func ParseVersion(s string) (Version, error) {
+	v, parts, err := parsePartial(s)
+	if err != nil {
+		return Version{}, err
+	}
+	if parts != 3 {
+		return Version{}, fmt.Errorf("invalid version %q: want MAJOR.MINOR.PATCH", s)
+	}
+	return v, nil
+}
+
+// String renders the version without a "v" prefix.
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns -1, 0, or 1 as v is lower than, equal to, or higher than o.
+func (v Version) Compare(o Version) int {
+	for _, d := range [3]int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// Bump returns the next "major", "minor", or "patch" release after v,
+// dropping any prerelease.
+func (v Version) Bump(part string) (Version, error) {
+	switch part {
+	case "major":
+		return Version{Major: v.Major + 1}, nil
+	case "minor":
+		return Version{Major: v.Major, Minor: v.Minor + 1}, nil
+	case "patch":
+		return Version{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}, nil
+	}
+	return Version{}, fmt.Errorf("unknown version part %q (expected major, minor, or patch)", part)
+}
+
+// parsePartial parses a version that may omit minor and patch ("1", "1.2")
+// or use "x"/"*" wildcards for them. It returns how many numeric parts were
+// given.
+func parsePartial(s string) (Version, int, error) {
+	orig := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v Version
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		v.Pre = s[i+1:]
+		s = s[:i]
+		if v.Pre == "" {
+			return Version{}, 0, fmt.Errorf("invalid version %q: empty prerelease", orig)
+		}
+	}
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 || s == "" {
+		return Version{}, 0, fmt.Errorf("invalid version %q", orig)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	parts := 0
+	for i, f := range fields {
+		if f == "x" || f == "X" || f == "*" {
+			break
+		}
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 || (len(f) > 1 && f[0] == '0') {
+			return Version{}, 0, fmt.Errorf("invalid version %q", orig)
+		}
+		*nums[i] = n
+		parts++
+	}
+	if v.Pre != "" && parts != 3 {
+		return Version{}, 0, fmt.Errorf("invalid version %q: prerelease needs MAJOR.MINOR.PATCH", orig)
+	}
+	return v, parts, nil
+}
+
+// comparePre orders prerelease strings per semver: no prerelease sorts after
+// any prerelease, identifiers compare numerically when both are numeric.
+func comparePre(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		switch {
+		case aerr == nil && berr == nil:
+			return sign(an - bn)
+		case aerr == nil:
+			return -1
+		case berr == nil:
+			return 1
+		default:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return sign(len(as) - len(bs))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// Constraint is a set of version comparators that must all hold, e.g.
+// "^1.2", "~0.3.1", ">=1.0.0 <2.0.0", or an exact "1.4.0".
+type Constraint struct {
+	raw    string
+	checks []comparator
+}
+
+type comparator struct {
+	op string // one of = > >= < <=
+	v  Version
+}
+
+// ParseConstraint parses a version constraint. Comparators are separated by
+// spaces or commas. An empty string, "*", or "latest" allows any release.
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: strings.TrimSpace(s)}
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, f := range fields {
+		if f == "*" || f == "x" || f == "latest" {
+			continue
+		}
+		checks, err := parseComparator(f)
+		if err != nil {
+			return nil, err
+		}
+		c.checks = append(c.checks, checks...)
+	}
+	return c, nil
+}
+
+// String returns the constraint as written.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Allows reports whether v satisfies every comparator. Prereleases are only
+// allowed when a comparator names a prerelease of the same MAJOR.MINOR.PATCH.
+func (c *Constraint) Allows(v Version) bool {
+	if v.Pre != "" {
+		ok := false
+		for _, ch := range c.checks {
+			if ch.v.Pre != "" && ch.v.Major == v.Major && ch.v.Minor == v.Minor && ch.v.Patch == v.Patch {
+				ok = true
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	for _, ch := range c.checks {
+		cmp := v.Compare(ch.v)
+		var pass bool
+		switch ch.op {
+		case "=":
+			pass = cmp == 0
+		case ">":
+			pass = cmp > 0
+		case ">=":
+			pass = cmp >= 0
+		case "<":
+			pass = cmp < 0
+		case "<=":
+			pass = cmp <= 0
+		}
+		if !pass {
+			return false
+		}
+	}
+	return true
+}
+
+// parseComparator expands one comparator into primitive checks.
+func parseComparator(f string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(f, prefix) {
+			op, f = prefix, f[len(prefix):]
+			break
+		}
+	}
+	v, parts, err := parsePartial(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid constraint: %w", err)
+	}
+	if parts == 0 {
+		if op == "" || op == "=" || op == "^" || op == "~" {
+			return nil, nil // bare wildcard
+		}
+		return nil, fmt.Errorf("invalid constraint %q", op+f)
+	}
+
+	lower := comparator{">=", v}
+	switch op {
+	case ">", ">=", "<", "<=":
+		if parts < 3 && (op == ">" || op == "<=") {
+			// ">1.2" means ">=1.3.0"; "<=1.2" means "<1.3.0".
+			next := bumpAt(v, parts)
+			if op == ">" {
+				return []comparator{{">=", next}}, nil
+			}
+			return []comparator{{"<", next}}, nil
+		}
+		return []comparator{{op, v}}, nil
+	case "^":
+		switch {
+		case v.Major > 0 || parts == 1:
+			return []comparator{lower, {"<", Version{Major: v.Major + 1}}}, nil
+		case v.Minor > 0 || parts == 2:
+			return []comparator{lower, {"<", Version{Minor: v.Minor + 1}}}, nil
+		default:
+			return []comparator{lower, {"<", Version{Patch: v.Patch + 1}}}, nil
+		}
+	case "~":
+		if parts == 1 {
+			return []comparator{lower, {"<", Version{Major: v.Major + 1}}}, nil
+		}
+		return []comparator{lower, {"<", Version{Major: v.Major, Minor: v.Minor + 1}}}, nil
+	default: // "" or "="
+		if parts == 3 {
+			return []comparator{{"=", v}}, nil
+		}
+		return []comparator{lower, {"<", bumpAt(v, parts)}}, nil
+	}
+}
+
+// bumpAt increments the last given part of a partial version.
+func bumpAt(v Version, parts int) Version {
+	if parts == 1 {
+		return Version{Major: v.Major + 1}
+	}
+	return Version{Major: v.Major, Minor: v.Minor + 1}
+}
@@ -0,0 +1,364 @@
+package packs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// KnownHookEvents are the agent runtime events a pack hook can be wired to.
+var KnownHookEvents = []string{
+	"PreToolUse", "PostToolUse", "UserPromptSubmit", "Notification", "Stop",
+	"SubagentStop", "PreCompact", "SessionStart", "SessionEnd",
+}
+
+// MaxHookTimeout is the longest timeout, in seconds, a pack may set.
+const MaxHookTimeout = 600
+
+// HookEvent is how a pack hook is wired into .claude/settings.json.
+type HookEvent struct {
+	Event   string `json:"event"`
+	Matcher string `json:"matcher,omitempty"`
+	Timeout int    `json:"timeout,omitempty"` // seconds; 0 uses the runtime default
+}
+
+// HookWiring is one settings.json entry that runs an installed hook.
+type HookWiring struct {
+	Event   string
+	Matcher string
+	Timeout int
+	// Managed is set for entries written by WireHook. Others were added by
+	// hand and are never changed.
+	Managed bool
+}
+
+// SettingsPath returns the workspace's agent settings file.
+func SettingsPath(workspace string) string {
+	return filepath.Join(workspace, ".claude", "settings.json")
+}
+
+// HookCommand is the command WireHook registers for a hook. Entries with
+// exactly this command belong to the marketplace.
+func HookCommand(name string) string {
+	return `"$CLAUDE_PROJECT_DIR"/.claude/hooks/` + name + ".sh"
+}
+
+// hookScriptRef finds the hook a settings.json command runs.
+var hookScriptRef = regexp.MustCompile(`\.claude/hooks/([A-Za-z0-9_-]+)\.sh\b`)
+
+// WireHook registers a hook for its event in .claude/settings.json,
+// replacing any entries WireHook wrote for it before. Other settings and
+// hand-written hook entries are kept as they are.
+func WireHook(workspace, name string, ev HookEvent) error {
+	return editHookSettings(workspace, func(hooks *jsonObject) (bool, error) {
+		if _, err := unwireHook(hooks, name); err != nil {
+			return false, err
+		}
+		entry := map[string]any{"type": "command", "command": HookCommand(name)}
+		if ev.Timeout > 0 {
+			entry["timeout"] = ev.Timeout
+		}
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			return false, err
+		}
+
+		var groups []json.RawMessage
+		if raw, ok := hooks.vals[ev.Event]; ok {
+			if err := json.Unmarshal(raw, &groups); err != nil {
+				return false, fmt.Errorf("hooks.%s: %w", ev.Event, err)
+			}
+		}
+		added := false
+		for i, g := range groups {
+			group, err := parseJSONObject(g)
+			if err != nil {
+				return false, fmt.Errorf("hooks.%s[%d]: %w", ev.Event, i, err)
+			}
+			var matcher string
+			json.Unmarshal(group.vals["matcher"], &matcher)
+			if matcher != ev.Matcher {
+				continue
+			}
+			var entries []json.RawMessage
+			json.Unmarshal(group.vals["hooks"], &entries)
+			if err := group.set("hooks", append(entries, encoded)); err != nil {
+				return false, err
+			}
+			if groups[i], err = group.marshal(); err != nil {
+				return false, err
+			}
+			added = true
+			break
+		}
+		if !added {
+			group := &jsonObject{vals: make(map[string]json.RawMessage)}
+			if ev.Matcher != "" {
+				group.set("matcher", ev.Matcher)
+			}
+			group.set("hooks", []json.RawMessage{encoded})
+			g, err := group.marshal()
+			if err != nil {
+				return false, err
+			}
+			groups = append(groups, g)
+		}
+		return true, hooks.set(ev.Event, groups)
+	})
+}
+
+// UnwireHook removes the entries WireHook wrote for a hook. Entries added by
+// hand are left alone.
+func UnwireHook(workspace, name string) error {
+	return editHookSettings(workspace, func(hooks *jsonObject) (bool, error) {
+		return unwireHook(hooks, name)
+	})
+}
+
+// unwireHook removes the managed entries for a hook from the settings hooks
+// object, dropping groups and events that become empty.
+func unwireHook(hooks *jsonObject, name string) (bool, error) {
+	command := HookCommand(name)
+	changed := false
+	for _, event := range append([]string(nil), hooks.keys...) {
+		var groups []json.RawMessage
+		if err := json.Unmarshal(hooks.vals[event], &groups); err != nil {
+			continue // not ours to repair
+		}
+		kept := groups[:0]
+		eventChanged := false
+		for _, g := range groups {
+			group, err := parseJSONObject(g)
+			if err != nil {
+				kept = append(kept, g)
+				continue
+			}
+			var entries []json.RawMessage
+			json.Unmarshal(group.vals["hooks"], &entries)
+			remaining := entries[:0:0]
+			for _, e := range entries {
+				var entry struct {
+					Command string `json:"command"`
+				}
+				if json.Unmarshal(e, &entry) == nil && entry.Command == command {
+					continue
+				}
+				remaining = append(remaining, e)
+			}
+			switch {
+			case len(remaining) == len(entries):
+				kept = append(kept, g)
+			case len(remaining) == 0:
+				eventChanged = true
+			default:
+				if err := group.set("hooks", remaining); err != nil {
+					return false, err
+				}
+				updated, err := group.marshal()
+				if err != nil {
+					return false, err
+				}
+				kept = append(kept, updated)
+				eventChanged = true
+			}
+		}
+		if !eventChanged {
+			continue
+		}
+		changed = true
+		if len(kept) == 0 {
+			hooks.del(event)
+		} else if err := hooks.set(event, kept); err != nil {
+			return false, err
+		}
+	}
+	return changed, nil
+}
+
+// ReadHookWiring returns the settings.json entries that run installed hooks
+// (commands under .claude/hooks/), keyed by hook name.
+func ReadHookWiring(workspace string) (map[string][]HookWiring, error) {
+	data, err := os.ReadFile(SettingsPath(workspace))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var settings struct {
+		Hooks map[string][]struct {
+			Matcher string `json:"matcher"`
+			Hooks   []struct {
+				Command string `json:"command"`
+				Timeout int    `json:"timeout"`
+			} `json:"hooks"`
+		} `json:"hooks"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("%s: %w", SettingsPath(workspace), err)
+	}
+	wiring := make(map[string][]HookWiring)
+	for event, groups := range settings.Hooks {
+		for _, g := range groups {
+			for _, h := range g.Hooks {
+				m := hookScriptRef.FindStringSubmatch(h.Command)
+				if m == nil {
+					continue
+				}
+				wiring[m[1]] = append(wiring[m[1]], HookWiring{
+					Event:   event,
+					Matcher: g.Matcher,
+					Timeout: h.Timeout,
+					Managed: h.Command == HookCommand(m[1]),
+				})
+			}
+		}
+	}
+	return wiring, nil
+}
+
+// editHookSettings applies edit to the "hooks" object of settings.json and
+// writes the file back if anything changed, keeping the order of existing
+// keys. A missing file is created only when there is something to add.
+func editHookSettings(workspace string, edit func(hooks *jsonObject) (bool, error)) error {
+	path := SettingsPath(workspace)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+	settings, err := parseJSONObject(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	hooks := &jsonObject{vals: make(map[string]json.RawMessage)}
+	if raw, ok := settings.vals["hooks"]; ok {
+		if hooks, err = parseJSONObject(raw); err != nil {
+			return fmt.Errorf("%s: hooks: %w", path, err)
+		}
+	}
+
+	changed, err := edit(hooks)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if !changed {
+		return nil
+	}
+	if len(hooks.keys) == 0 {
+		settings.del("hooks")
+	} else if err := settings.set("hooks", hooks); err != nil {
+		return err
+	}
+	out, err := settings.indent()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, out, 0644)
+}
+
+// jsonObject is a JSON object that keeps the order of its keys, so files the
+// user edits by hand are rewritten with minimal changes.
+type jsonObject struct {
+	keys []string
+	vals map[string]json.RawMessage
+}
+
+func parseJSONObject(data []byte) (*jsonObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, errors.New("must be a JSON object")
+	}
+	o := &jsonObject{vals: make(map[string]json.RawMessage)}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		k := t.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, err
+		}
+		if _, dup := o.vals[k]; !dup {
+			o.keys = append(o.keys, k)
+		}
+		o.vals[k] = raw
+	}
+	return o, nil
+}
+
+// set encodes value under key, appending the key if it is new.
+func (o *jsonObject) set(key string, value any) error {
+	var encoded []byte
+	var err error
+	if nested, ok := value.(*jsonObject); ok {
+		encoded, err = nested.marshal()
+	} else {
+		encoded, err = json.Marshal(value)
+	}
+	if err != nil {
+		return err
+	}
+	if _, ok := o.vals[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.vals[key] = encoded
+	return nil
+}
+
+func (o *jsonObject) del(key string) {
+	if _, ok := o.vals[key]; !ok {
+		return
+	}
+	delete(o.vals, key)
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+}
+
+// marshal encodes the object compactly, in key order.
+func (o *jsonObject) marshal() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, _ := json.Marshal(k)
+		b.Write(name)
+		b.WriteByte(':')
+		if err := json.Compact(&b, o.vals[k]); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// indent encodes the object indented with two spaces and a final newline.
+func (o *jsonObject) indent() ([]byte, error) {
+	compact, err := o.marshal()
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact, "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
@@ -0,0 +1,151 @@
+package packs
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// SignatureFile is the detached minisign signature of pack.json, next to it
+// in the pack repo. Authors create it with `minisign -Sm pack.json`.
+const SignatureFile = "pack.json.minisig"
+
+// Minisign signature algorithms: "Ed" signs the message itself, "ED" (the
+// default since minisign 0.10) signs its BLAKE2b-512 hash.
+const (
+	sigAlgEd       = "Ed"
+	sigAlgPrehash  = "ED"
+	trustedComment = "trusted comment: "
+)
+
+// PublicKey is a minisign Ed25519 public key.
+type PublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// ParsePublicKey parses a minisign public key: the base64 line starting with
+// "RW", optionally preceded by its "untrusted comment:" line as found in a
+// minisign.pub file.
+func ParsePublicKey(s string) (*PublicKey, error) {
+	line := lastLine(s)
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != sigAlgEd {
+		return nil, fmt.Errorf("invalid minisign public key %q", truncate(line, 16))
+	}
+	pk := &PublicKey{Key: ed25519.PublicKey(raw[10:])}
+	copy(pk.ID[:], raw[2:10])
+	return pk, nil
+}
+
+// FormatPublicKey renders a key as the base64 line of a minisign.pub file.
+func FormatPublicKey(pk *PublicKey) string {
+	raw := append([]byte(sigAlgEd), pk.ID[:]...)
+	return base64.StdEncoding.EncodeToString(append(raw, pk.Key...))
+}
+
+// KeyID returns the key ID as minisign prints it.
+func (pk *PublicKey) KeyID() string {
+	return keyID(pk.ID)
+}
+
+func keyID(id [8]byte) string {
+	return fmt.Sprintf("%016X", binary.LittleEndian.Uint64(id[:]))
+}
+
+// Signature is a parsed minisign signature file.
+type Signature struct {
+	Algorithm      string
+	KeyID          [8]byte
+	Sig            []byte
+	TrustedComment string
+	GlobalSig      []byte
+}
+
+// ParseSignature parses the four-line minisign signature format.
+func ParseSignature(data []byte) (*Signature, error) {
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], trustedComment) {
+		return nil, errors.New("not a minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return nil, errors.New("malformed minisign signature")
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return nil, errors.New("malformed minisign trusted comment signature")
+	}
+	sig := &Signature{
+		Algorithm:      string(raw[:2]),
+		Sig:            raw[10:],
+		TrustedComment: strings.TrimPrefix(lines[2], trustedComment),
+		GlobalSig:      global,
+	}
+	copy(sig.KeyID[:], raw[2:10])
+	if sig.Algorithm != sigAlgEd && sig.Algorithm != sigAlgPrehash {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+	return sig, nil
+}
+
+// Verify checks the signature of message and of the trusted comment against
+// the key.
+func (s *Signature) Verify(pk *PublicKey, message []byte) error {
+	if s.KeyID != pk.ID {
+		return fmt.Errorf("signed with key %s, not %s", keyID(s.KeyID), pk.KeyID())
+	}
+	signed := message
+	if s.Algorithm == sigAlgPrehash {
+		sum := blake2b.Sum512(message)
+		signed = sum[:]
+	}
+	if !ed25519.Verify(pk.Key, signed, s.Sig) {
+		return errors.New("signature does not match pack.json")
+	}
+	if !ed25519.Verify(pk.Key, append(bytes.Clone(s.Sig), s.TrustedComment...), s.GlobalSig) {
+		return errors.New("trusted comment signature is invalid")
+	}
+	return nil
+}
+
+// VerifySignature checks a minisign signature of message against a set of
+// trusted keys and returns the ID of the key that verified it.
+func VerifySignature(message, signature []byte, trusted []string) (string, error) {
+	sig, err := ParseSignature(signature)
+	if err != nil {
+		return "", err
+	}
+	for _, k := range trusted {
+		pk, err := ParsePublicKey(k)
+		if err != nil {
+			return "", err
+		}
+		if pk.ID == sig.KeyID {
+			if err := sig.Verify(pk, message); err != nil {
+				return "", err
+			}
+			return pk.KeyID(), nil
+		}
+	}
+	return "", fmt.Errorf("signed with key %s, which is not trusted", keyID(sig.KeyID))
+}
+
+// lastLine returns the last non-empty line of s, trimmed.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
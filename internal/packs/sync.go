@@ -0,0 +1,586 @@
+package packs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/orchestra-mcp/sdk-go/workflow"
+	"gopkg.in/yaml.v3"
+)
+
+// SyncKinds are the content types kept in sync between .claude/ and the
+// .skills/, .agents/, .hooks/, and .workflows/ storage folders.
+var SyncKinds = []string{ContentSkills, ContentAgents, ContentHooks, ContentWorkflows}
+
+// Sync directions.
+const (
+	SyncBoth      = "both"
+	SyncToStorage = "to_storage"
+	SyncToFiles   = "to_files"
+)
+
+// Conflict policies: report the conflict, or let one side win.
+const (
+	ConflictSkip    = "skip"
+	ConflictFile    = "file"
+	ConflictStorage = "storage"
+	ConflictNewer   = "newer"
+)
+
+// Sync operations.
+const (
+	SyncUnchanged    = "unchanged"
+	SyncImport       = "import"        // file to storage
+	SyncExport       = "export"        // storage to file
+	SyncDeleteStored = "delete_stored" // the file was deleted
+	SyncDeleteFile   = "delete_file"   // the storage entry was deleted
+	SyncLink         = "link"          // both sides already agree
+	SyncRetag        = "retag"         // only storage-only metadata is out of date
+	SyncForget       = "forget"        // both sides are gone
+	SyncConflict     = "conflict"
+	SyncSkip         = "skip" // a change the direction does not carry
+)
+
+// storageOnlyFields are metadata fields that describe the storage entry
+// rather than the file, so they are kept on import and never written to a
+// file. The file's name is a slug while storage holds a display name, so
+// name is also never written back.
+var storageOnlyFields = []string{"name", "slug", "scope", "created_at", "updated_at", "event_type", "matcher", "timeout", "pack", "pack_version", "pack_commit", "source_path", "modified"}
+
+// SyncOptions selects what a sync covers and how it resolves conflicts.
+type SyncOptions struct {
+	Direction  string   // SyncBoth (default), SyncToStorage, or SyncToFiles
+	OnConflict string   // ConflictSkip (default), ConflictFile, ConflictStorage, or ConflictNewer
+	Types      []string // content kinds (default: SyncKinds)
+	Names      []string // slugs or globs (default: all)
+}
+
+// SyncRecord is the state of an item after its last sync, used to tell which
+// side changed since. An empty FileHash records that there was no file, as
+// for a hook exported from storage that is still pending review.
+type SyncRecord struct {
+	FileHash   string `json:"file_hash"`
+	StoredHash string `json:"stored_hash"`
+	SyncedAt   string `json:"synced_at"`
+}
+
+// SyncFile is the .claude/ side of an item.
+type SyncFile struct {
+	Content []byte
+	ModTime time.Time
+	Mode    fs.FileMode
+}
+
+// SyncStored is the storage side of an item.
+type SyncStored struct {
+	Body     []byte
+	Metadata map[string]any
+	Version  int64
+	ModTime  time.Time
+}
+
+// SyncItem is one skill, agent, hook, or workflow with whichever sides exist
+// and its record from the last sync.
+type SyncItem struct {
+	Kind   string
+	Slug   string
+	File   *SyncFile
+	Stored *SyncStored
+	Record *SyncRecord
+
+	// Tags are storage-only fields the entry should carry, such as the pack
+	// that installed the file or the event a hook is wired to. A nil value
+	// removes the field.
+	Tags map[string]any
+}
+
+// SyncAction is what a sync does, or would do, with one item.
+type SyncAction struct {
+	Kind   string
+	Slug   string
+	Op     string
+	Reason string
+	Err    error // set by the caller if applying the action failed
+}
+
+// SyncKey identifies an item in sync records: "skills/go-backend".
+func SyncKey(kind, slug string) string {
+	return kind + "/" + slug
+}
+
+// SyncStoragePath is the storage entry of an item: ".skills/go-backend.md".
+func SyncStoragePath(kind, slug string) string {
+	return "." + kind + "/" + slug + ".md"
+}
+
+// SyncFilePath is the workspace file of an item. A workflow's slug is its
+// file name without the extension; a new workflow file is written as .yaml.
+func SyncFilePath(workspace, kind, slug string) string {
+	switch kind {
+	case ContentSkills:
+		return filepath.Join(workspace, ".claude", "skills", slug, "SKILL.md")
+	case ContentAgents:
+		return filepath.Join(workspace, ".claude", "agents", slug+".md")
+	case ContentWorkflows:
+		dir := filepath.Join(workspace, ".claude", "workflows")
+		if _, err := os.Stat(filepath.Join(dir, slug+".yaml")); err != nil {
+			if _, err := os.Stat(filepath.Join(dir, slug+".yml")); err == nil {
+				return filepath.Join(dir, slug+".yml")
+			}
+		}
+		return filepath.Join(dir, slug+".yaml")
+	default:
+		return HookPath(workspace, slug)
+	}
+}
+
+// ScanSyncFiles reads the skills, agents, hooks, and workflows of the given
+// kinds under .claude/, keyed by SyncKey. Hooks pending review are not
+// included.
+func ScanSyncFiles(workspace string, kinds []string) map[string]*SyncFile {
+	files := make(map[string]*SyncFile)
+	for _, kind := range kinds {
+		var slugs []string
+		switch kind {
+		case ContentSkills:
+			slugs = ListInstalledSkills(workspace)
+		case ContentAgents:
+			slugs = ListInstalledAgents(workspace)
+		case ContentHooks:
+			slugs = ListInstalledHooks(workspace)
+		case ContentWorkflows:
+			for _, name := range ListInstalledWorkflows(workspace) {
+				slugs = append(slugs, strings.TrimSuffix(name, filepath.Ext(name)))
+			}
+		}
+		for _, slug := range slugs {
+			if f, err := ReadSyncFile(workspace, kind, slug); err == nil {
+				files[SyncKey(kind, slug)] = f
+			}
+		}
+	}
+	return files
+}
+
+// ReadSyncFile reads the workspace file of one item.
+func ReadSyncFile(workspace, kind, slug string) (*SyncFile, error) {
+	p := SyncFilePath(workspace, kind, slug)
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncFile{Content: data, ModTime: info.ModTime(), Mode: info.Mode().Perm()}, nil
+}
+
+// ContentHash returns "sha256:<hex>" for content.
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// StoredHash hashes the part of a storage entry that a file holds: the body
+// and, for skills and agents, the frontmatter fields. Storage-only metadata
+// such as scope and timestamps does not count as a change.
+func StoredHash(kind string, body []byte, metadata map[string]any) string {
+	h := sha256.New()
+	h.Write(body)
+	if !storedVerbatim(kind) {
+		fields := frontmatterFields(metadata)
+		encoded, _ := json.Marshal(fields) // map keys are sorted
+		h.Write([]byte{0})
+		h.Write(encoded)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// storedVerbatim reports whether a kind is stored as the file itself, with
+// metadata that only describes it: hooks and workflows.
+func storedVerbatim(kind string) bool {
+	return kind == ContentHooks || kind == ContentWorkflows
+}
+
+// frontmatterFields returns the metadata fields that belong in a file's
+// frontmatter.
+func frontmatterFields(metadata map[string]any) map[string]any {
+	fields := maps.Clone(metadata)
+	if fields == nil {
+		fields = map[string]any{}
+	}
+	for _, k := range storageOnlyFields {
+		delete(fields, k)
+	}
+	return fields
+}
+
+// StoredFromFile converts a workspace file to a storage body and metadata.
+// Skills and agents are stored without frontmatter and their frontmatter
+// fields become metadata; storage-only fields of the previous metadata, such
+// as scope and created_at, are kept. Hooks are stored as the script itself
+// with their metadata unchanged. Workflows are stored as the YAML file, with
+// its name, description, initial state, and states as metadata.
+func StoredFromFile(kind, slug string, content []byte, previous map[string]any) ([]byte, map[string]any) {
+	meta := make(map[string]any)
+	switch kind {
+	case ContentHooks:
+		maps.Copy(meta, previous)
+		if meta["name"] == nil {
+			meta["name"] = slug
+		}
+		meta["slug"] = slug
+		return content, meta
+	case ContentWorkflows:
+		maps.Copy(meta, previous)
+		for _, k := range []string{"description", "initial_state", "states"} {
+			delete(meta, k)
+		}
+		meta["name"] = slug
+		var def workflow.WorkflowDefinition
+		if yaml.Unmarshal(content, &def) == nil {
+			if def.Name != "" {
+				meta["name"] = def.Name
+			}
+			if def.Description != "" {
+				meta["description"] = def.Description
+			}
+			if def.InitialState != "" {
+				meta["initial_state"] = string(def.InitialState)
+			}
+			states := make([]any, 0, len(def.States))
+			for _, id := range slices.Sorted(maps.Keys(def.States)) {
+				states = append(states, string(id))
+			}
+			meta["states"] = states
+		}
+		meta["slug"] = slug
+		return content, meta
+	}
+
+	fm, body, err := ParseFrontmatter(string(content))
+	if err != nil {
+		// Keep what storage knows rather than dropping fields of a file
+		// that does not parse.
+		maps.Copy(meta, previous)
+		meta["slug"] = slug
+		return []byte(body), meta
+	}
+	for _, k := range storageOnlyFields {
+		if v, ok := previous[k]; ok {
+			meta[k] = v
+		}
+	}
+	if fm != nil {
+		maps.Copy(meta, fm.Metadata())
+	}
+	switch {
+	case fm != nil && fm.Name != "":
+		meta["name"] = fm.Name
+	case previous["name"] != nil:
+		meta["name"] = previous["name"]
+	default:
+		meta["name"] = toTitleCase(slug)
+	}
+	meta["slug"] = slug
+	return []byte(body), meta
+}
+
+// FileFromStored converts a storage entry to the content of its workspace
+// file. Skills and agents get their frontmatter back from the metadata,
+// keeping the formatting of fields previous already had; the frontmatter
+// name stays as it was, or is the slug for a new file.
+func FileFromStored(kind, slug string, body []byte, metadata map[string]any, previous []byte) ([]byte, error) {
+	if storedVerbatim(kind) {
+		return body, nil
+	}
+	fields := frontmatterFields(metadata)
+	fields["name"] = slug
+	if fm, _, err := ParseFrontmatter(string(previous)); err == nil && fm != nil && fm.fields["name"] != nil {
+		fields["name"] = fm.fields["name"]
+	}
+	out, err := RenderFrontmatter(string(previous), fields, string(body))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// PlanSync decides what to do with each item. A side has changed when its
+// hash differs from the record of the last sync; when both have, the items
+// conflict unless their content agrees. Conflicts are resolved by
+// opts.OnConflict, using modification times for ConflictNewer, and changes
+// the direction does not carry are skipped. Actions are sorted by kind and
+// slug.
+func PlanSync(items []SyncItem, opts SyncOptions) []SyncAction {
+	var actions []SyncAction
+	for _, it := range items {
+		if !opts.selects(it.Kind, it.Slug) {
+			continue
+		}
+		a := planItem(it)
+		if (a.Op == SyncUnchanged || a.Op == SyncLink) && it.Stored != nil && !hasTags(it.Stored.Metadata, it.Tags) {
+			a.Op, a.Reason = SyncRetag, a.Reason+"; updating storage metadata"
+		}
+		if a.Op == SyncConflict {
+			a = resolveConflict(it, a, opts.OnConflict)
+		}
+		if !opts.allows(a.Op) {
+			a.Reason = fmt.Sprintf("%s; direction %s does not %s", a.Reason, opts.Direction, a.Op)
+			a.Op = SyncSkip
+		}
+		actions = append(actions, a)
+	}
+	slices.SortFunc(actions, func(a, b SyncAction) int {
+		if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Slug, b.Slug)
+	})
+	return actions
+}
+
+func planItem(it SyncItem) SyncAction {
+	a := SyncAction{Kind: it.Kind, Slug: it.Slug}
+	f, s, r := it.File, it.Stored, it.Record
+	var fileHash, storedHash string
+	if f != nil {
+		fileHash = ContentHash(f.Content)
+	}
+	if s != nil {
+		storedHash = StoredHash(it.Kind, s.Body, s.Metadata)
+	}
+	set := func(op, reason string) SyncAction {
+		a.Op, a.Reason = op, reason
+		return a
+	}
+
+	switch {
+	case f == nil && s == nil:
+		return set(SyncForget, "deleted on both sides")
+
+	case f != nil && s != nil:
+		fileChanged := r == nil || r.FileHash != fileHash
+		storedChanged := r == nil || r.StoredHash != storedHash
+		if !fileChanged && !storedChanged {
+			return set(SyncUnchanged, "in sync")
+		}
+		body, meta := StoredFromFile(it.Kind, it.Slug, f.Content, s.Metadata)
+		if StoredHash(it.Kind, body, meta) == storedHash {
+			return set(SyncLink, "content already matches")
+		}
+		switch {
+		case r == nil && bytes.Equal(body, s.Body):
+			return set(SyncImport, "first sync; bodies match, copying the file's frontmatter to storage")
+		case r == nil:
+			return set(SyncConflict, "both sides exist with different content and were never synced")
+		case fileChanged && storedChanged:
+			return set(SyncConflict, "changed on both sides since the last sync")
+		case fileChanged:
+			return set(SyncImport, "file changed")
+		default:
+			return set(SyncExport, "storage changed")
+		}
+
+	case f != nil:
+		switch {
+		case r == nil:
+			return set(SyncImport, "new file")
+		case r.FileHash != fileHash:
+			return set(SyncConflict, "deleted from storage, but the file changed since the last sync")
+		default:
+			return set(SyncDeleteFile, "deleted from storage")
+		}
+
+	default:
+		switch {
+		case r == nil:
+			return set(SyncExport, "new in storage")
+		case r.FileHash == "" && r.StoredHash == storedHash:
+			return set(SyncUnchanged, "not in the workspace yet; pending hook review")
+		case r.FileHash == "":
+			return set(SyncExport, "storage changed")
+		case r.StoredHash != storedHash:
+			return set(SyncConflict, "file deleted, but storage changed since the last sync")
+		default:
+			return set(SyncDeleteStored, "file deleted")
+		}
+	}
+}
+
+// resolveConflict applies a conflict policy. ConflictSkip, or an unknown
+// policy, leaves the conflict for the user.
+func resolveConflict(it SyncItem, a SyncAction, policy string) SyncAction {
+	fileWins := func() SyncAction {
+		a.Op = SyncImport
+		if it.File == nil {
+			a.Op = SyncDeleteStored
+		}
+		a.Reason += "; the file wins"
+		return a
+	}
+	storageWins := func() SyncAction {
+		a.Op = SyncExport
+		if it.Stored == nil {
+			a.Op = SyncDeleteFile
+		}
+		a.Reason += "; storage wins"
+		return a
+	}
+	switch policy {
+	case ConflictFile:
+		return fileWins()
+	case ConflictStorage:
+		return storageWins()
+	case ConflictNewer:
+		switch {
+		case it.Stored == nil: // the changed file is the newer side
+			return fileWins()
+		case it.File == nil:
+			return storageWins()
+		case it.File.ModTime.After(it.Stored.ModTime):
+			return fileWins()
+		default:
+			return storageWins()
+		}
+	}
+	return a
+}
+
+// ApplyTags sets the tags on metadata, deleting those whose value is nil.
+func ApplyTags(metadata, tags map[string]any) {
+	for k, v := range tags {
+		if v == nil {
+			delete(metadata, k)
+		} else {
+			metadata[k] = v
+		}
+	}
+}
+
+// hasTags reports whether metadata already carries the tags, comparing JSON
+// forms as storage returns numbers as floats.
+func hasTags(metadata, tags map[string]any) bool {
+	for k, v := range tags {
+		cur, ok := metadata[k]
+		if v == nil {
+			if ok {
+				return false
+			}
+			continue
+		}
+		a, _ := json.Marshal(cur)
+		b, _ := json.Marshal(v)
+		if !ok || !bytes.Equal(a, b) {
+			return false
+		}
+	}
+	return true
+}
+
+func (o SyncOptions) selects(kind, slug string) bool {
+	if len(o.Types) > 0 && !slices.Contains(o.Types, kind) {
+		return false
+	}
+	if len(o.Names) == 0 {
+		return true
+	}
+	for _, pattern := range o.Names {
+		if ok, _ := path.Match(pattern, slug); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allows reports whether the direction carries an operation. Operations
+// that only update sync records are always allowed.
+func (o SyncOptions) allows(op string) bool {
+	switch op {
+	case SyncImport, SyncDeleteStored:
+		return o.Direction != SyncToFiles
+	case SyncExport, SyncDeleteFile:
+		return o.Direction != SyncToStorage
+	}
+	return true
+}
+
+// WriteSyncFile writes the workspace file of an item, creating directories
+// as needed, and keeps the mode of an existing file. A hook is never written
+// over its installed script: it is staged in .claude/hooks/.pending/ without
+// the executable bit, so content from storage runs only once approve_hook
+// has activated it.
+func WriteSyncFile(workspace, kind, slug string, content []byte) error {
+	claude := filepath.Join(workspace, ".claude")
+	if err := os.MkdirAll(claude, 0755); err != nil {
+		return err
+	}
+	file := SyncFilePath(workspace, kind, slug)
+	if kind == ContentHooks {
+		file = PendingHookPath(workspace, slug)
+	}
+	rel, err := filepath.Rel(claude, file)
+	if err != nil {
+		return err
+	}
+	dest, err := confinedPath(claude, rel)
+	if err != nil {
+		return err
+	}
+	mode := fs.FileMode(0644)
+	if info, err := os.Lstat(dest); err == nil && info.Mode().IsRegular() && kind != ContentHooks {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := removeLink(dest); err != nil {
+		return err
+	}
+	tmp := dest + ".sync-tmp"
+	if err := os.WriteFile(tmp, content, mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// DeleteSyncFile removes the workspace file of an item. A skill directory is
+// removed too once SKILL.md was its only file; a hook loses its pending copy
+// and the settings.json entries WireHook wrote, so nothing points at the
+// deleted script.
+func DeleteSyncFile(workspace, kind, slug string) error {
+	claude := filepath.Join(workspace, ".claude")
+	rel, err := filepath.Rel(claude, SyncFilePath(workspace, kind, slug))
+	if err != nil {
+		return err
+	}
+	dest, err := confinedPath(claude, rel)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	switch kind {
+	case ContentSkills:
+		os.Remove(filepath.Dir(dest)) // fails, harmlessly, if other files remain
+	case ContentHooks:
+		if err := DiscardPendingHook(workspace, slug); err != nil {
+			return err
+		}
+		return UnwireHook(workspace, slug)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package packs
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// DefaultFetchWorkers bounds how many pack repos are cloned concurrently.
+const DefaultFetchWorkers = 4
+
+// FetchRequest names a repo to fetch and the version constraint recorded
+// when it was installed. An empty constraint fetches the default branch.
+type FetchRequest struct {
+	Repo       string
+	Constraint string
+}
+
+// FetchResult is the outcome of fetching one repo in FetchAll.
+type FetchResult struct {
+	Repo string
+	Pack *FetchedPack
+	Err  error
+}
+
+// FetchAll fetches the newest version of each repo allowed by its constraint
+// using at most workers concurrent clones. Results are returned in the same
+// order as reqs, and a failure for one repo does not stop the others.
+func FetchAll(ctx context.Context, reqs []FetchRequest, workers int) []FetchResult {
+	return fetchAll(ctx, reqs, workers, func(ctx context.Context, repo, constraint string) (*FetchedPack, error) {
+		ref, err := ResolveRef(ctx, repo, constraint)
+		if err != nil {
+			return nil, err
+		}
+		return FetchPack(ctx, repo, ref)
+	})
+}
+
+func fetchAll(ctx context.Context, reqs []FetchRequest, workers int, fetch func(context.Context, string, string) (*FetchedPack, error)) []FetchResult {
+	results := make([]FetchResult, len(reqs))
+	parallel(len(reqs), workers, func(i int) {
+		pack, err := fetch(ctx, reqs[i].Repo, reqs[i].Constraint)
+		results[i] = FetchResult{Repo: reqs[i].Repo, Pack: pack, Err: err}
+	})
+	return results
+}
+
+// parallel calls fn for each index in [0, n) using at most workers goroutines
+// and returns once every call has finished.
+func parallel(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// DependencyOrder returns the pack names in requires so that every pack comes
+// after the packs it requires. Requirements on packs outside the set are
+// ignored. Ties, and any packs caught in a dependency cycle, are ordered by
+// name so the result is deterministic.
+func DependencyOrder(requires map[string][]string) []string {
+	names := make([]string, 0, len(requires))
+	for name := range requires {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pending := make(map[string]int, len(names))
+	dependents := make(map[string][]string)
+	for _, name := range names {
+		for _, dep := range requires[name] {
+			if _, ok := requires[dep]; !ok || dep == name {
+				continue
+			}
+			pending[name]++
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var order []string
+	done := make(map[string]bool, len(names))
+	for len(order) < len(names) {
+		progressed := false
+		for _, name := range names {
+			if done[name] || pending[name] > 0 {
+				continue
+			}
+			done[name] = true
+			order = append(order, name)
+			for _, d := range dependents[name] {
+				pending[d]--
+			}
+			progressed = true
+			break
+		}
+		if !progressed {
+			// Cycle: release the first remaining pack by name.
+			for _, name := range names {
+				if !done[name] {
+					pending[name] = 0
+					break
+				}
+			}
+		}
+	}
+	return order
+}
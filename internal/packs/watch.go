@@ -0,0 +1,201 @@
+package packs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchKinds are the .claude/ directories WatchContent watches.
+var WatchKinds = []string{ContentSkills, ContentAgents, ContentHooks, ContentWorkflows}
+
+// DefaultWatchDebounce is how long WatchContent waits for events to stop
+// before reporting them, so an editor's save or a pack install is reported
+// once.
+const DefaultWatchDebounce = 500 * time.Millisecond
+
+// ContentChange is an item whose workspace files were created, changed, or
+// removed. An empty Slug means the whole directory of that kind changed,
+// e.g. it was created or deleted, and every item of the kind needs a look.
+type ContentChange struct {
+	Kind string
+	Slug string
+}
+
+// WatchContent watches .claude/skills, .claude/agents, .claude/hooks, and
+// .claude/workflows until ctx is done, calling onChange with what changed
+// once no event has arrived for debounce. Calls to onChange never overlap;
+// events that arrive meanwhile are reported by the next call. Directories,
+// .claude/ included, may be created or deleted while it runs. It returns nil
+// when ctx is done, or an error if the watcher cannot be started.
+func WatchContent(ctx context.Context, workspace string, debounce time.Duration, onChange func([]ContentChange)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	cw := &contentWatcher{w: w, workspace: workspace, claude: filepath.Join(workspace, ".claude")}
+	if err := w.Add(workspace); err != nil {
+		return err
+	}
+	cw.addClaude()
+
+	pending := make(map[ContentChange]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			for _, c := range cw.handle(ev) {
+				pending[c] = true
+			}
+			if len(pending) > 0 {
+				timer.Reset(debounce)
+			}
+
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were dropped; look at everything again.
+				for _, kind := range WatchKinds {
+					pending[ContentChange{Kind: kind}] = true
+				}
+				timer.Reset(debounce)
+			}
+
+		case <-timer.C:
+			changes := make([]ContentChange, 0, len(pending))
+			for c := range pending {
+				changes = append(changes, c)
+			}
+			clear(pending)
+			slices.SortFunc(changes, func(a, b ContentChange) int {
+				if c := strings.Compare(a.Kind, b.Kind); c != 0 {
+					return c
+				}
+				return strings.Compare(a.Slug, b.Slug)
+			})
+			onChange(changes)
+		}
+	}
+}
+
+type contentWatcher struct {
+	w         *fsnotify.Watcher
+	workspace string
+	claude    string
+}
+
+// addClaude watches .claude/ and every content directory under it that
+// exists. fsnotify is not recursive, so skill directories are watched one by
+// one.
+func (cw *contentWatcher) addClaude() {
+	if cw.w.Add(cw.claude) != nil {
+		return
+	}
+	for _, kind := range WatchKinds {
+		cw.addKind(kind)
+	}
+}
+
+func (cw *contentWatcher) addKind(kind string) {
+	dir := filepath.Join(cw.claude, kind)
+	if cw.w.Add(dir) != nil || kind != ContentSkills {
+		return
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if e.IsDir() && !strings.HasPrefix(e.Name(), ".") {
+			cw.w.Add(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// handle maps an event to the items it affects, adding watches for
+// directories as they appear. A new directory is reported as a whole, since
+// files may have been written into it before the watch was added.
+func (cw *contentWatcher) handle(ev fsnotify.Event) []ContentChange {
+	if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) {
+		return nil
+	}
+	created := ev.Has(fsnotify.Create) && isDir(ev.Name)
+
+	if filepath.Dir(ev.Name) == cw.workspace {
+		if filepath.Base(ev.Name) != ".claude" {
+			return nil
+		}
+		if created {
+			cw.addClaude()
+		}
+		return allKinds()
+	}
+
+	rel, err := filepath.Rel(cw.claude, ev.Name)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	kind := parts[0]
+	if !slices.Contains(WatchKinds, kind) {
+		return nil
+	}
+	if len(parts) == 1 {
+		if created {
+			cw.addKind(kind)
+		}
+		return []ContentChange{{Kind: kind}}
+	}
+	name := parts[1]
+	if strings.HasPrefix(name, ".") {
+		return nil // .pending hooks, editor and temporary files
+	}
+	switch kind {
+	case ContentSkills:
+		if len(parts) == 2 && created {
+			cw.w.Add(ev.Name)
+		}
+		return []ContentChange{{Kind: kind, Slug: name}}
+	case ContentAgents:
+		if len(parts) == 2 && strings.HasSuffix(name, ".md") {
+			return []ContentChange{{Kind: kind, Slug: strings.TrimSuffix(name, ".md")}}
+		}
+	case ContentHooks:
+		if len(parts) == 2 && strings.HasSuffix(name, ".sh") {
+			return []ContentChange{{Kind: kind, Slug: strings.TrimSuffix(name, ".sh")}}
+		}
+	case ContentWorkflows:
+		if len(parts) == 2 && (strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, ".yml")) {
+			return []ContentChange{{Kind: kind, Slug: strings.TrimSuffix(name, filepath.Ext(name))}}
+		}
+	}
+	return nil
+}
+
+func allKinds() []ContentChange {
+	changes := make([]ContentChange, len(WatchKinds))
+	for i, kind := range WatchKinds {
+		changes[i] = ContentChange{Kind: kind}
+	}
+	return changes
+}
+
+func isDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,144 @@
+package packs
+
+import (
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/orchestra-mcp/sdk-go/workflow"
+)
+
+// ListInstalledWorkflows returns the file names of the workflow definitions
+// in .claude/workflows/, sorted.
+func ListInstalledWorkflows(workspace string) []string {
+	entries, err := os.ReadDir(filepath.Join(workspace, ".claude", "workflows"))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && workflowPattern.MatchString(e.Name()) {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// WorkflowFile resolves a workflow given by file name, with or without its
+// .yaml or .yml extension, to the file name in .claude/workflows/. The error
+// wraps fs.ErrNotExist when the name is valid but no such file exists.
+func WorkflowFile(workspace, name string) (string, error) {
+	candidates := []string{name}
+	if ext := filepath.Ext(name); ext != ".yaml" && ext != ".yml" {
+		candidates = []string{name + ".yaml", name + ".yml"}
+	}
+	for _, file := range candidates {
+		if !workflowPattern.MatchString(file) {
+			return "", fmt.Errorf("invalid workflow name %q", name)
+		}
+		if _, err := os.Stat(filepath.Join(workspace, ".claude", "workflows", file)); err == nil {
+			return file, nil
+		}
+	}
+	return "", &fs.PathError{Op: "find workflow", Path: ".claude/workflows/" + name, Err: fs.ErrNotExist}
+}
+
+// WorkflowStates returns the state IDs in lifecycle order: the initial state,
+// then states as transitions reach them, then any unreachable states sorted.
+func WorkflowStates(def *workflow.WorkflowDefinition) []string {
+	var order []string
+	seen := make(map[string]bool)
+	visit := func(id string) {
+		if _, ok := def.States[workflow.StateID(id)]; ok && !seen[id] {
+			seen[id] = true
+			order = append(order, id)
+		}
+	}
+	visit(string(def.InitialState))
+	for i := 0; i < len(order); i++ {
+		for _, t := range def.Transitions {
+			if t.From == order[i] {
+				visit(t.To)
+			}
+		}
+	}
+	var rest []string
+	for id := range def.States {
+		if !seen[string(id)] {
+			rest = append(rest, string(id))
+		}
+	}
+	slices.Sort(rest)
+	return append(order, rest...)
+}
+
+// DescribeWorkflow renders a workflow's states, transitions, and gates as
+// markdown tables, followed by any consistency problems.
+func DescribeWorkflow(def *workflow.WorkflowDefinition) string {
+	var b strings.Builder
+	cell := func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return strings.ReplaceAll(s, "|", "\\|")
+	}
+
+	states := WorkflowStates(def)
+	fmt.Fprintf(&b, "### States (%d)\n\n", len(states))
+	if len(states) > 0 {
+		fmt.Fprintf(&b, "| State | Label | Kind |\n|---|---|---|\n")
+		for _, id := range states {
+			s := def.States[workflow.StateID(id)]
+			var kind []string
+			if id == string(def.InitialState) {
+				kind = append(kind, "initial")
+			}
+			if s.ActiveWork {
+				kind = append(kind, "active work")
+			}
+			if s.Terminal {
+				kind = append(kind, "terminal")
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", id, cell(s.Label), cell(strings.Join(kind, ", ")))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n### Transitions (%d)\n\n", len(def.Transitions))
+	if len(def.Transitions) > 0 {
+		fmt.Fprintf(&b, "| From | To | Gate |\n|---|---|---|\n")
+		for _, t := range def.Transitions {
+			gate := "-"
+			if t.Gate != "" {
+				gate = "`" + t.Gate + "`"
+				if g, ok := def.Gates[t.Gate]; ok && g.Label != "" {
+					gate = cell(g.Label) + " (" + gate + ")"
+				}
+			}
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", t.From, t.To, gate)
+		}
+	}
+
+	gates := slices.Sorted(maps.Keys(def.Gates))
+	fmt.Fprintf(&b, "\n### Gates (%d)\n\n", len(gates))
+	if len(gates) > 0 {
+		fmt.Fprintf(&b, "| Gate | Label | Required section | File patterns | Docs folder | Skippable for |\n|---|---|---|---|---|---|\n")
+		for _, id := range gates {
+			g := def.Gates[id]
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n", id, cell(g.Label), cell(g.RequiredSection),
+				cell(strings.Join(g.FilePatterns, ", ")), cell(g.DocsFolder), cell(strings.Join(g.SkippableFor, ", ")))
+		}
+	}
+
+	if problems := workflowProblems(def); len(problems) > 0 {
+		fmt.Fprintf(&b, "\n### Problems (%d)\n\n", len(problems))
+		for _, p := range problems {
+			fmt.Fprintf(&b, "- %s\n", p)
+		}
+	}
+	return b.String()
+}
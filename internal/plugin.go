@@ -2,6 +2,7 @@ package internal
 
 import (
 	"github.com/orchestra-mcp/sdk-go/plugin"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
 	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
 	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/tools"
 )
@@ -10,23 +11,40 @@ import (
 type MarketplacePlugin struct {
 	Storage   *storage.PackStorage
 	Workspace string
+
+	// Lock serialises mutating pack operations on the workspace. It is
+	// created on first registration when left nil.
+	Lock *packs.WorkspaceLock
 }
 
-// RegisterTools registers all 24 marketplace tools with the plugin builder.
+// RegisterTools registers all 40 marketplace tools with the plugin builder.
 func (mp *MarketplacePlugin) RegisterTools(builder *plugin.PluginBuilder) {
 	ps := mp.Storage
 	ws := mp.Workspace
+	if mp.Lock == nil {
+		mp.Lock = packs.NewWorkspaceLock(ws)
+	}
+	lock := mp.Lock
 
-	// --- Pack management (6) ---
+	// --- Pack management (9) ---
 	builder.RegisterTool("install_pack",
 		"Install a pack of skills, agents, and hooks from a GitHub repo",
-		tools.InstallPackSchema(), tools.InstallPack(ps, ws))
+		tools.InstallPackSchema(), tools.InstallPack(ps, ws, lock))
 	builder.RegisterTool("remove_pack",
 		"Remove an installed pack and its contents",
-		tools.RemovePackSchema(), tools.RemovePack(ps, ws))
+		tools.RemovePackSchema(), tools.RemovePack(ps, ws, lock))
 	builder.RegisterTool("update_pack",
-		"Update an installed pack to the latest version",
-		tools.UpdatePackSchema(), tools.UpdatePack(ps, ws))
+		"Update installed packs to the newest version allowed by their constraints",
+		tools.UpdatePackSchema(), tools.UpdatePack(ps, ws, lock))
+	builder.RegisterTool("outdated_packs",
+		"Check installed packs for newer versions without installing them",
+		tools.OutdatedPacksSchema(), tools.OutdatedPacks(ps))
+	builder.RegisterTool("verify_packs",
+		"Re-check installed pack files against the digests recorded at install",
+		tools.VerifyPacksSchema(), tools.VerifyPacks(ps, ws))
+	builder.RegisterTool("license_report",
+		"Report the licenses of installed packs and flag missing or disallowed ones",
+		tools.LicenseReportSchema(), tools.LicenseReport(ps, ws))
 	builder.RegisterTool("list_packs",
 		"List all installed packs",
 		tools.ListPacksSchema(), tools.ListPacks(ps))
@@ -37,6 +55,34 @@ func (mp *MarketplacePlugin) RegisterTools(builder *plugin.PluginBuilder) {
 		"Search available packs by keyword or stack",
 		tools.SearchPacksSchema(), tools.SearchPacks(ps))
 
+	// --- Pack authoring (3) ---
+	builder.RegisterTool("lint_pack",
+		"Check a local pack directory for manifest, frontmatter, link, hook, and workflow problems",
+		tools.LintPackSchema(), tools.LintPack(ws))
+	builder.RegisterTool("create_pack",
+		"Scaffold a new pack directory with a manifest, example content, README, and CHANGELOG",
+		tools.CreatePackSchema(), tools.CreatePack(ws))
+	builder.RegisterTool("export_pack",
+		"Export workspace and stored skills, agents, hooks, and workflows as a publishable pack",
+		tools.ExportPackSchema(), tools.ExportPack(ps, ws))
+
+	// --- Hook review (5) ---
+	builder.RegisterTool("review_hooks",
+		"Show the source of pack hooks awaiting approval and what changed since the approved version",
+		tools.ReviewHooksSchema(), tools.ReviewHooks(ps, ws))
+	builder.RegisterTool("approve_hook",
+		"Approve a pending pack hook so it is installed and executable",
+		tools.ApproveHookSchema(), tools.ApproveHook(ps, ws, lock))
+	builder.RegisterTool("reject_hook",
+		"Reject a pending pack hook and keep it out of the workspace",
+		tools.RejectHookSchema(), tools.RejectHook(ps, ws, lock))
+	builder.RegisterTool("audit_hooks",
+		"Scan installed, pending, and stored hooks for risky shell constructs",
+		tools.AuditHooksSchema(), tools.AuditHooks(ps, ws))
+	builder.RegisterTool("test_hook",
+		"Run a hook with a synthetic event payload in a sandbox and report its exit code, output, and runtime",
+		tools.TestHookSchema(), tools.TestHook(ps, ws))
+
 	// --- Recommendations (2) ---
 	builder.RegisterTool("detect_stacks",
 		"Detect the project's technology stacks",
@@ -45,22 +91,36 @@ func (mp *MarketplacePlugin) RegisterTools(builder *plugin.PluginBuilder) {
 		"Recommend packs based on detected technology stacks",
 		tools.RecommendPacksSchema(), tools.RecommendPacks(ps, ws))
 
-	// --- Content queries (5) ---
+	// --- Content queries (8) ---
 	builder.RegisterTool("list_skills",
-		"List all installed skills",
-		tools.ListSkillsSchema(), tools.ListSkills(ws))
+		"List skills from .claude and storage, with filters, sorting, and pagination",
+		tools.ListSkillsSchema(), tools.ListSkills(ps, ws))
 	builder.RegisterTool("list_agents",
-		"List all installed agents",
-		tools.ListAgentsSchema(), tools.ListAgents(ws))
+		"List agents from .claude and storage, with filters, sorting, and pagination",
+		tools.ListAgentsSchema(), tools.ListAgents(ps, ws))
 	builder.RegisterTool("list_hooks",
-		"List all installed hooks",
-		tools.ListHooksSchema(), tools.ListHooks(ws))
+		"List hooks from .claude and storage, with wiring and risk",
+		tools.ListHooksSchema(), tools.ListHooks(ps, ws))
 	builder.RegisterTool("get_skill",
 		"Read a skill's full content",
-		tools.GetSkillSchema(), tools.GetSkill(ws))
+		tools.GetSkillSchema(), tools.GetSkill(ps, ws))
 	builder.RegisterTool("get_agent",
 		"Read an agent's full content",
-		tools.GetAgentSchema(), tools.GetAgent(ws))
+		tools.GetAgentSchema(), tools.GetAgent(ps, ws))
+	builder.RegisterTool("get_hook",
+		"Read a hook's script with its wiring, risk, and pack",
+		tools.GetHookSchema(), tools.GetHook(ps, ws))
+	builder.RegisterTool("list_workflows",
+		"List installed workflows and the projects they are applied to",
+		tools.ListWorkflowsSchema(), tools.ListWorkflows(ps, ws))
+	builder.RegisterTool("get_workflow",
+		"Show a workflow's states, transitions, and gates",
+		tools.GetWorkflowSchema(), tools.GetWorkflow(ps, ws))
+
+	// --- Content sync (1) ---
+	builder.RegisterTool("sync_content",
+		"Sync skills, agents, hooks, and workflows between .claude/ and storage, detecting changes and conflicts on both sides",
+		tools.SyncContentSchema(), tools.SyncContent(ps, ws, lock))
 
 	// --- Skill CRUD (3) ---
 	builder.RegisterTool("create_skill",
@@ -95,13 +155,16 @@ func (mp *MarketplacePlugin) RegisterTools(builder *plugin.PluginBuilder) {
 		"Delete a hook by slug",
 		tools.DeleteHookSchema(), tools.DeleteHook(ps))
 
-	// --- Configuration (2) ---
+	// --- Configuration (3) ---
 	builder.RegisterTool("set_project_stacks",
 		"Manually set the project's technology stacks",
 		tools.SetProjectStacksSchema(), tools.SetProjectStacks(ps))
 	builder.RegisterTool("get_project_stacks",
 		"Get the project's detected or configured stacks",
 		tools.GetProjectStacksSchema(), tools.GetProjectStacks(ps, ws))
+	builder.RegisterTool("set_trusted_keys",
+		"Set the minisign keys that must sign packs from a repo prefix",
+		tools.SetTrustedKeysSchema(), tools.SetTrustedKeys(ps))
 }
 
 // RegisterPrompts registers all 5 marketplace prompts with the plugin builder.
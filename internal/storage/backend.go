@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
+	"github.com/orchestra-mcp/sdk-go/helpers"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ErrNotFound is returned by backends when a path does not exist.
+var ErrNotFound = errors.New("not found")
+
+// ErrVersionConflict is returned by backends when an optimistic write's
+// expected version does not match the stored version.
+var ErrVersionConflict = errors.New("version conflict")
+
+// StorageBackend performs the raw storage operations that PackStorage is built
+// on. Paths are relative to the storage root (e.g. ".packs/registry.json").
+//
+// Writes use optimistic concurrency: an expectedVersion of 0 creates a new
+// entry and fails if one exists; any other value must match the stored version.
+type StorageBackend interface {
+	Read(ctx context.Context, path string) (*pluginv1.StorageReadResponse, error)
+	Write(ctx context.Context, path string, metadata *structpb.Struct, content []byte, expectedVersion int64) (int64, error)
+	Delete(ctx context.Context, path string) error
+	List(ctx context.Context, prefix, pattern string) ([]*pluginv1.StorageEntry, error)
+}
+
+// clientBackend is the StorageBackend that forwards every operation to the
+// orchestrator's markdown storage over a StorageClient.
+type clientBackend struct {
+	client StorageClient
+}
+
+// NewClientBackend returns a StorageBackend that talks to the orchestrator.
+func NewClientBackend(client StorageClient) StorageBackend {
+	return &clientBackend{client: client}
+}
+
+func (b *clientBackend) Read(ctx context.Context, path string) (*pluginv1.StorageReadResponse, error) {
+	resp, err := b.client.Send(ctx, &pluginv1.PluginRequest{
+		RequestId: helpers.NewUUID(),
+		Request: &pluginv1.PluginRequest_StorageRead{
+			StorageRead: &pluginv1.StorageReadRequest{
+				Path:        path,
+				StorageType: "markdown",
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sr := resp.GetStorageRead()
+	if sr == nil {
+		return nil, fmt.Errorf("unexpected response type for storage read")
+	}
+	return sr, nil
+}
+
+func (b *clientBackend) Write(ctx context.Context, path string, metadata *structpb.Struct, content []byte, expectedVersion int64) (int64, error) {
+	resp, err := b.client.Send(ctx, &pluginv1.PluginRequest{
+		RequestId: helpers.NewUUID(),
+		Request: &pluginv1.PluginRequest_StorageWrite{
+			StorageWrite: &pluginv1.StorageWriteRequest{
+				Path:            path,
+				Content:         content,
+				Metadata:        metadata,
+				ExpectedVersion: expectedVersion,
+				StorageType:     "markdown",
+			},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	sw := resp.GetStorageWrite()
+	if sw == nil {
+		return 0, fmt.Errorf("unexpected response type for storage write")
+	}
+	if !sw.Success {
+		if isConflictMessage(sw.Error) {
+			return 0, fmt.Errorf("storage write failed: %w: %s", ErrVersionConflict, sw.Error)
+		}
+		return 0, fmt.Errorf("storage write failed: %s", sw.Error)
+	}
+	return sw.NewVersion, nil
+}
+
+// isConflictMessage reports whether a storage write error from the
+// orchestrator describes an expected-version mismatch. The protocol only
+// carries a message, so this matches on its wording.
+func isConflictMessage(msg string) bool {
+	msg = strings.ToLower(msg)
+	if !strings.Contains(msg, "version") {
+		return false
+	}
+	return strings.Contains(msg, "conflict") || strings.Contains(msg, "mismatch") ||
+		strings.Contains(msg, "expected") || strings.Contains(msg, "already exists")
+}
+
+func (b *clientBackend) Delete(ctx context.Context, path string) error {
+	resp, err := b.client.Send(ctx, &pluginv1.PluginRequest{
+		RequestId: helpers.NewUUID(),
+		Request: &pluginv1.PluginRequest_StorageDelete{
+			StorageDelete: &pluginv1.StorageDeleteRequest{
+				Path:        path,
+				StorageType: "markdown",
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	sd := resp.GetStorageDelete()
+	if sd == nil {
+		return fmt.Errorf("unexpected response type for storage delete")
+	}
+	if !sd.Success {
+		return fmt.Errorf("storage delete failed for path: %s", path)
+	}
+	return nil
+}
+
+func (b *clientBackend) List(ctx context.Context, prefix, pattern string) ([]*pluginv1.StorageEntry, error) {
+	resp, err := b.client.Send(ctx, &pluginv1.PluginRequest{
+		RequestId: helpers.NewUUID(),
+		Request: &pluginv1.PluginRequest_StorageList{
+			StorageList: &pluginv1.StorageListRequest{
+				Prefix:      prefix,
+				Pattern:     pattern,
+				StorageType: "markdown",
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	sl := resp.GetStorageList()
+	if sl == nil {
+		return nil, fmt.Errorf("unexpected response type for storage list")
+	}
+	return sl.Entries, nil
+}
@@ -3,10 +3,13 @@ package storage
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
 
 	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
-	"github.com/orchestra-mcp/sdk-go/helpers"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
@@ -18,28 +21,117 @@ type StorageClient interface {
 // PackRegistry holds all installed packs.
 type PackRegistry struct {
 	Packs map[string]*PackEntry `json:"packs"`
+
+	// TrustedKeys maps a repo prefix (e.g. "github.com/acme", or "*" for
+	// every repo) to the minisign public keys whose signatures are accepted
+	// for packs from it. Packs from a prefix with keys must be signed.
+	TrustedKeys map[string][]string `json:"trusted_keys,omitempty"`
+
+	// HookApprovals records the review state of every hook installed from a
+	// pack, keyed by hook name.
+	HookApprovals map[string]*HookApproval `json:"hook_approvals,omitempty"`
+
+	// ContentSync records the state of every skill, agent, and hook after its
+	// last sync between .claude and storage, keyed by "kind/slug".
+	ContentSync map[string]*SyncRecord `json:"content_sync,omitempty"`
+}
+
+// SyncRecord is the content hash of both sides of a synced item.
+type SyncRecord struct {
+	FileHash   string `json:"file_hash"`
+	StoredHash string `json:"stored_hash"`
+	SyncedAt   string `json:"synced_at"`
+}
+
+// Hook review states.
+const (
+	HookPending  = "pending"
+	HookApproved = "approved"
+	HookRejected = "rejected"
+)
+
+// HookApproval is the review state of one pack hook. Digest is the content
+// the status applies to; ApprovedDigest is the last approved content, which
+// stays installed while a changed version is pending.
+type HookApproval struct {
+	Pack           string `json:"pack"`
+	Status         string `json:"status"`
+	Digest         string `json:"digest"`
+	ApprovedDigest string `json:"approved_digest,omitempty"`
+	ReviewedAt     string `json:"reviewed_at,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// KeysFor returns the trusted keys for a repo from its longest matching
+// prefix, falling back to "*".
+func (r *PackRegistry) KeysFor(repo string) []string {
+	best := ""
+	for prefix := range r.TrustedKeys {
+		if prefix == "*" || len(prefix) <= len(best) {
+			continue
+		}
+		if repo == prefix || strings.HasPrefix(repo, strings.TrimSuffix(prefix, "/")+"/") {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return r.TrustedKeys["*"]
+	}
+	return r.TrustedKeys[best]
 }
 
 // PackEntry describes a single installed pack.
 type PackEntry struct {
 	Version     string   `json:"version"`
 	Repo        string   `json:"repo"`
+	Commit      string   `json:"commit,omitempty"`
+	Constraint  string   `json:"constraint,omitempty"`
 	InstalledAt string   `json:"installed_at"`
 	Stacks      []string `json:"stacks"`
+	Requires    []string `json:"requires,omitempty"`
 	Skills      []string `json:"skills"`
 	Agents      []string `json:"agents"`
 	Hooks       []string `json:"hooks"`
 	Workflows   []string `json:"workflows,omitempty"`
+	License     string   `json:"license,omitempty"`
+
+	// Digests records "sha256:<hex>" for every installed file, keyed by
+	// path relative to .claude, so verify_packs can detect changes.
+	Digests map[string]string `json:"digests,omitempty"`
+	// Signer is the ID of the trusted key that signed the installed version.
+	Signer string `json:"signer,omitempty"`
+	// HookEvents records the settings.json wiring the pack declares for its
+	// hooks, applied when each hook is approved.
+	HookEvents map[string]HookEvent `json:"hook_events,omitempty"`
+}
+
+// HookEvent is the agent runtime event a pack hook is wired to.
+type HookEvent struct {
+	Event   string `json:"event"`
+	Matcher string `json:"matcher,omitempty"`
+	Timeout int    `json:"timeout,omitempty"`
 }
 
 // PackStorage provides operations for reading and writing the pack registry.
 type PackStorage struct {
-	client StorageClient
+	backend StorageBackend
 }
 
 // NewPackStorage creates a new PackStorage backed by the given client.
 func NewPackStorage(client StorageClient) *PackStorage {
-	return &PackStorage{client: client}
+	return &PackStorage{backend: NewClientBackend(client)}
+}
+
+// NewPackStorageWithBackend creates a new PackStorage on an arbitrary backend,
+// such as a FileBackend for running without an orchestrator.
+func NewPackStorageWithBackend(backend StorageBackend) *PackStorage {
+	return &PackStorage{backend: backend}
+}
+
+// SetBackend swaps the storage backend. It must be called before any tool
+// handler runs; main uses it to apply the --storage flag after flag parsing.
+func (ps *PackStorage) SetBackend(backend StorageBackend) {
+	ps.backend = backend
 }
 
 const registryPath = ".packs/registry.json"
@@ -58,6 +150,46 @@ func (ps *PackStorage) ReadRegistry(ctx context.Context) (*PackRegistry, int64,
 	asMap := resp.Metadata.AsMap()
 	reg := &PackRegistry{Packs: make(map[string]*PackEntry)}
 
+	if keys, ok := asMap["trusted_keys"].(map[string]any); ok {
+		reg.TrustedKeys = make(map[string][]string, len(keys))
+		for prefix, list := range keys {
+			items, _ := list.([]any)
+			for _, k := range items {
+				if s, ok := k.(string); ok {
+					reg.TrustedKeys[prefix] = append(reg.TrustedKeys[prefix], s)
+				}
+			}
+		}
+	}
+
+	if approvals, ok := asMap["hook_approvals"].(map[string]any); ok {
+		reg.HookApprovals = make(map[string]*HookApproval, len(approvals))
+		for name, raw := range approvals {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				continue
+			}
+			var a HookApproval
+			if err := json.Unmarshal(data, &a); err == nil {
+				reg.HookApprovals[name] = &a
+			}
+		}
+	}
+
+	if records, ok := asMap["content_sync"].(map[string]any); ok {
+		reg.ContentSync = make(map[string]*SyncRecord, len(records))
+		for key, raw := range records {
+			data, err := json.Marshal(raw)
+			if err != nil {
+				continue
+			}
+			var r SyncRecord
+			if err := json.Unmarshal(data, &r); err == nil {
+				reg.ContentSync[key] = &r
+			}
+		}
+	}
+
 	// Extract "packs" from the metadata — handle both map and array formats.
 	packsRaw, ok := asMap["packs"]
 	if !ok {
@@ -129,6 +261,52 @@ func (ps *PackStorage) WriteRegistry(ctx context.Context, reg *PackRegistry, exp
 	return ps.storageWrite(ctx, registryPath, meta, nil, expectedVersion)
 }
 
+// Registry retry tuning for UpdateRegistry. Variables so tests can shorten them.
+var (
+	registryMaxAttempts  = 6
+	registryRetryBackoff = 20 * time.Millisecond
+)
+
+// UpdateRegistry performs an optimistic read-modify-write of the registry.
+// fn is applied to a freshly read registry; if the write loses a race with a
+// concurrent writer, the registry is re-read and fn re-applied, with
+// exponential backoff between attempts.
+//
+// An error returned by fn aborts the update and is returned unchanged. If
+// every attempt conflicts, the returned error wraps ErrVersionConflict; any
+// other storage failure is returned as-is without retrying.
+func (ps *PackStorage) UpdateRegistry(ctx context.Context, fn func(*PackRegistry) error) (*PackRegistry, error) {
+	backoff := registryRetryBackoff
+	for attempt := 1; ; attempt++ {
+		reg, version, err := ps.ReadRegistry(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn(reg); err != nil {
+			return nil, err
+		}
+		_, err = ps.WriteRegistry(ctx, reg, version)
+		if err == nil {
+			return reg, nil
+		}
+		if !errors.Is(err, ErrVersionConflict) {
+			return nil, err
+		}
+		if attempt >= registryMaxAttempts {
+			return nil, fmt.Errorf("update registry: gave up after %d attempts: %w", attempt, err)
+		}
+
+		// Jitter spreads out writers that collided on the same version.
+		wait := backoff + time.Duration(rand.Int64N(int64(backoff)))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
 // ReadStacks reads the manually configured stacks from storage.
 func (ps *PackStorage) ReadStacks(ctx context.Context) ([]string, int64, error) {
 	resp, err := ps.storageRead(ctx, stacksPath)
@@ -167,8 +345,13 @@ func (ps *PackStorage) WriteStacks(ctx context.Context, stacks []string, expecte
 }
 
 // Send delegates to the underlying storage client for direct storage operations.
+// It is only available when PackStorage is backed by an orchestrator client.
 func (ps *PackStorage) Send(ctx context.Context, req *pluginv1.PluginRequest) (*pluginv1.PluginResponse, error) {
-	return ps.client.Send(ctx, req)
+	cb, ok := ps.backend.(*clientBackend)
+	if !ok {
+		return nil, fmt.Errorf("storage backend does not accept raw plugin requests")
+	}
+	return cb.client.Send(ctx, req)
 }
 
 // StorageRead performs a low-level storage read and returns the response.
@@ -183,72 +366,21 @@ func (ps *PackStorage) StorageWrite(ctx context.Context, path string, metadata *
 
 // StorageDelete performs a low-level storage delete.
 func (ps *PackStorage) StorageDelete(ctx context.Context, path string) error {
-	resp, err := ps.client.Send(ctx, &pluginv1.PluginRequest{
-		RequestId: helpers.NewUUID(),
-		Request: &pluginv1.PluginRequest_StorageDelete{
-			StorageDelete: &pluginv1.StorageDeleteRequest{
-				Path:        path,
-				StorageType: "markdown",
-			},
-		},
-	})
-	if err != nil {
-		return err
-	}
-	sd := resp.GetStorageDelete()
-	if sd == nil {
-		return fmt.Errorf("unexpected response type for storage delete")
-	}
-	if !sd.Success {
-		return fmt.Errorf("storage delete failed for path: %s", path)
-	}
-	return nil
+	return ps.backend.Delete(ctx, path)
+}
+
+// StorageList lists entries under prefix whose file names match pattern
+// (all entries when pattern is empty).
+func (ps *PackStorage) StorageList(ctx context.Context, prefix, pattern string) ([]*pluginv1.StorageEntry, error) {
+	return ps.backend.List(ctx, prefix, pattern)
 }
 
 // --- Low-level storage protocol ---
 
 func (ps *PackStorage) storageRead(ctx context.Context, path string) (*pluginv1.StorageReadResponse, error) {
-	resp, err := ps.client.Send(ctx, &pluginv1.PluginRequest{
-		RequestId: helpers.NewUUID(),
-		Request: &pluginv1.PluginRequest_StorageRead{
-			StorageRead: &pluginv1.StorageReadRequest{
-				Path:        path,
-				StorageType: "markdown",
-			},
-		},
-	})
-	if err != nil {
-		return nil, err
-	}
-	sr := resp.GetStorageRead()
-	if sr == nil {
-		return nil, fmt.Errorf("unexpected response type for storage read")
-	}
-	return sr, nil
+	return ps.backend.Read(ctx, path)
 }
 
 func (ps *PackStorage) storageWrite(ctx context.Context, path string, metadata *structpb.Struct, content []byte, expectedVersion int64) (int64, error) {
-	resp, err := ps.client.Send(ctx, &pluginv1.PluginRequest{
-		RequestId: helpers.NewUUID(),
-		Request: &pluginv1.PluginRequest_StorageWrite{
-			StorageWrite: &pluginv1.StorageWriteRequest{
-				Path:            path,
-				Content:         content,
-				Metadata:        metadata,
-				ExpectedVersion: expectedVersion,
-				StorageType:     "markdown",
-			},
-		},
-	})
-	if err != nil {
-		return 0, err
-	}
-	sw := resp.GetStorageWrite()
-	if sw == nil {
-		return 0, fmt.Errorf("unexpected response type for storage write")
-	}
-	if !sw.Success {
-		return 0, fmt.Errorf("storage write failed: %s", sw.Error)
-	}
-	return sw.NewVersion, nil
+	return ps.backend.Write(ctx, path, metadata, content, expectedVersion)
 }
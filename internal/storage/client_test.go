@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -295,3 +298,244 @@ func TestParsePackEntry_MalformedInput(t *testing.T) {
 		t.Error("expected error for non-marshalable input")
 	}
 }
+
+// conflictBackend wraps a backend and fails the first n writes with a
+// version conflict, counting write attempts.
+type conflictBackend struct {
+	StorageBackend
+	conflicts int
+	writes    int
+	writeErr  error
+}
+
+func (c *conflictBackend) Write(ctx context.Context, path string, metadata *structpb.Struct, content []byte, expectedVersion int64) (int64, error) {
+	c.writes++
+	if c.writeErr != nil {
+		return 0, c.writeErr
+	}
+	if c.conflicts > 0 {
+		c.conflicts--
+		return 0, fmt.Errorf("injected: %w", ErrVersionConflict)
+	}
+	return c.StorageBackend.Write(ctx, path, metadata, content, expectedVersion)
+}
+
+func fastRetries(t *testing.T) {
+	t.Helper()
+	oldBackoff := registryRetryBackoff
+	registryRetryBackoff = time.Millisecond
+	t.Cleanup(func() { registryRetryBackoff = oldBackoff })
+}
+
+func TestUpdateRegistry_RetriesOnConflict(t *testing.T) {
+	fastRetries(t)
+	backend := &conflictBackend{StorageBackend: NewFileBackend(t.TempDir()), conflicts: 2}
+	ps := NewPackStorageWithBackend(backend)
+
+	applied := 0
+	reg, err := ps.UpdateRegistry(context.Background(), func(reg *PackRegistry) error {
+		applied++
+		reg.Packs["pack-a"] = &PackEntry{Version: "1.0.0"}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied != 3 || backend.writes != 3 {
+		t.Errorf("expected 3 attempts, got %d applies and %d writes", applied, backend.writes)
+	}
+	if reg.Packs["pack-a"] == nil {
+		t.Error("expected pack-a in returned registry")
+	}
+}
+
+func TestUpdateRegistry_GivesUpWithConflictError(t *testing.T) {
+	fastRetries(t)
+	backend := &conflictBackend{StorageBackend: NewFileBackend(t.TempDir()), conflicts: 1000}
+	ps := NewPackStorageWithBackend(backend)
+
+	_, err := ps.UpdateRegistry(context.Background(), func(*PackRegistry) error { return nil })
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected ErrVersionConflict, got %v", err)
+	}
+	if backend.writes != registryMaxAttempts {
+		t.Errorf("expected %d writes, got %d", registryMaxAttempts, backend.writes)
+	}
+}
+
+func TestUpdateRegistry_OtherErrorsNotRetried(t *testing.T) {
+	fastRetries(t)
+	backend := &conflictBackend{StorageBackend: NewFileBackend(t.TempDir()), writeErr: fmt.Errorf("disk full")}
+	ps := NewPackStorageWithBackend(backend)
+
+	_, err := ps.UpdateRegistry(context.Background(), func(*PackRegistry) error { return nil })
+	if err == nil || errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected plain storage error, got %v", err)
+	}
+	if backend.writes != 1 {
+		t.Errorf("expected 1 write, got %d", backend.writes)
+	}
+}
+
+func TestUpdateRegistry_MutationErrorAborts(t *testing.T) {
+	ps := NewPackStorageWithBackend(NewFileBackend(t.TempDir()))
+	sentinel := errors.New("nope")
+
+	_, err := ps.UpdateRegistry(context.Background(), func(*PackRegistry) error { return sentinel })
+	if !errors.Is(err, sentinel) {
+		t.Errorf("expected mutation error, got %v", err)
+	}
+}
+
+func TestUpdateRegistry_ConcurrentWriters(t *testing.T) {
+	fastRetries(t)
+	oldAttempts := registryMaxAttempts
+	registryMaxAttempts = 100
+	t.Cleanup(func() { registryMaxAttempts = oldAttempts })
+
+	ps := NewPackStorageWithBackend(NewFileBackend(t.TempDir()))
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			name := fmt.Sprintf("pack-%d", i)
+			if _, err := ps.UpdateRegistry(ctx, func(reg *PackRegistry) error {
+				reg.Packs[name] = &PackEntry{Version: "1.0.0"}
+				return nil
+			}); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	reg, _, err := ps.ReadRegistry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reg.Packs) != 8 {
+		t.Errorf("expected 8 packs after concurrent updates, got %d", len(reg.Packs))
+	}
+}
+
+func TestClientBackend_ConflictMessage(t *testing.T) {
+	client := &mockClient{
+		response: &pluginv1.PluginResponse{
+			Response: &pluginv1.PluginResponse_StorageWrite{
+				StorageWrite: &pluginv1.StorageWriteResponse{Error: "version mismatch: expected 3, got 4"},
+			},
+		},
+	}
+	ps := NewPackStorage(client)
+	_, err := ps.WriteRegistry(context.Background(), &PackRegistry{Packs: map[string]*PackEntry{}}, 3)
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict, got %v", err)
+	}
+}
+
+func TestKeysFor(t *testing.T) {
+	reg := &PackRegistry{TrustedKeys: map[string][]string{
+		"*":                       {"any"},
+		"github.com/acme":         {"acme"},
+		"github.com/acme/pack-go": {"go"},
+	}}
+	cases := map[string]string{
+		"github.com/acme/pack-go":   "go",
+		"github.com/acme/pack-rust": "acme",
+		"github.com/acme-evil/pack": "any",
+		"github.com/other/pack-go":  "any",
+	}
+	for repo, want := range cases {
+		if got := reg.KeysFor(repo); len(got) != 1 || got[0] != want {
+			t.Errorf("KeysFor(%q) = %v, want [%s]", repo, got, want)
+		}
+	}
+	if got := (&PackRegistry{}).KeysFor("github.com/acme/x"); got != nil {
+		t.Errorf("expected no keys, got %v", got)
+	}
+}
+
+func TestReadRegistry_TrustedKeys(t *testing.T) {
+	client := &mockClient{
+		response: makeStorageReadResponse(map[string]any{
+			"packs": map[string]any{
+				"acme/pack-go": map[string]any{
+					"version": "1.0.0",
+					"repo":    "github.com/acme/pack-go",
+					"signer":  "0706050403020101",
+					"digests": map[string]any{"hooks/notify.sh": "sha256:abc"},
+				},
+			},
+			"trusted_keys": map[string]any{
+				"github.com/acme": []any{"RWkey1", "RWkey2"},
+			},
+		}, 1),
+	}
+	ps := NewPackStorage(client)
+
+	reg, _, err := ps.ReadRegistry(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys := reg.TrustedKeys["github.com/acme"]; len(keys) != 2 || keys[1] != "RWkey2" {
+		t.Errorf("unexpected trusted keys %v", reg.TrustedKeys)
+	}
+	entry := reg.Packs["acme/pack-go"]
+	if entry.Signer != "0706050403020101" || entry.Digests["hooks/notify.sh"] != "sha256:abc" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+}
+
+func TestReadRegistry_HookApprovals(t *testing.T) {
+	client := &mockClient{
+		response: makeStorageReadResponse(map[string]any{
+			"packs": map[string]any{},
+			"hook_approvals": map[string]any{
+				"notify": map[string]any{
+					"pack":            "acme/pack-go",
+					"status":          HookPending,
+					"digest":          "sha256:new",
+					"approved_digest": "sha256:old",
+				},
+			},
+		}, 1),
+	}
+	ps := NewPackStorage(client)
+
+	reg, _, err := ps.ReadRegistry(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a := reg.HookApprovals["notify"]
+	if a == nil || a.Pack != "acme/pack-go" || a.Status != HookPending || a.Digest != "sha256:new" || a.ApprovedDigest != "sha256:old" {
+		t.Errorf("unexpected approval %+v", a)
+	}
+}
+
+func TestReadRegistry_ContentSync(t *testing.T) {
+	client := &mockClient{
+		response: makeStorageReadResponse(map[string]any{
+			"packs": map[string]any{},
+			"content_sync": map[string]any{
+				"skills/go-backend": map[string]any{
+					"file_hash":   "sha256:file",
+					"stored_hash": "sha256:stored",
+					"synced_at":   "2026-01-02T03:04:05Z",
+				},
+			},
+		}, 1),
+	}
+	ps := NewPackStorage(client)
+
+	reg, _, err := ps.ReadRegistry(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r := reg.ContentSync["skills/go-backend"]
+	if r == nil || r.FileHash != "sha256:file" || r.StoredHash != "sha256:stored" || r.SyncedAt != "2026-01-02T03:04:05Z" {
+		t.Errorf("unexpected record %+v", r)
+	}
+}
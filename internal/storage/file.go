@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+// versionKey is the reserved frontmatter key holding an entry's version.
+const versionKey = "_version"
+
+// FileBackend is a StorageBackend that reads and writes storage entries
+// directly on disk under a root directory (normally <workspace>/.projects),
+// so the plugin can run without an orchestrator.
+//
+// Each entry is a markdown file whose YAML frontmatter holds the metadata and
+// version, followed by the content body — the same layout the markdown
+// storage plugin uses, so both can share a workspace.
+type FileBackend struct {
+	root string
+	mu   sync.Mutex
+}
+
+// NewFileBackend creates a FileBackend rooted at dir.
+func NewFileBackend(dir string) *FileBackend {
+	return &FileBackend{root: dir}
+}
+
+// Root returns the directory entries are stored under.
+func (b *FileBackend) Root() string {
+	return b.root
+}
+
+func (b *FileBackend) Read(_ context.Context, path string) (*pluginv1.StorageReadResponse, error) {
+	full, err := b.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return readEntry(full, path)
+}
+
+func (b *FileBackend) Write(_ context.Context, path string, metadata *structpb.Struct, content []byte, expectedVersion int64) (int64, error) {
+	full, err := b.resolve(path)
+	if err != nil {
+		return 0, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var current int64
+	existing, err := readEntry(full, path)
+	switch {
+	case err == nil:
+		current = existing.Version
+	case !errors.Is(err, ErrNotFound):
+		return 0, err
+	}
+	if expectedVersion == 0 && existing != nil {
+		return 0, fmt.Errorf("%w: %s already exists at version %d", ErrVersionConflict, path, current)
+	}
+	if expectedVersion != 0 && expectedVersion != current {
+		return 0, fmt.Errorf("%w: %s expected version %d, found %d", ErrVersionConflict, path, expectedVersion, current)
+	}
+
+	newVersion := current + 1
+	data, err := encodeEntry(metadata, content, newVersion)
+	if err != nil {
+		return 0, fmt.Errorf("encode %s: %w", path, err)
+	}
+	if err := writeFileAtomic(full, data); err != nil {
+		return 0, fmt.Errorf("write %s: %w", path, err)
+	}
+	return newVersion, nil
+}
+
+func (b *FileBackend) Delete(_ context.Context, path string) error {
+	full, err := b.resolve(path)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := os.Remove(full); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return err
+	}
+	return nil
+}
+
+func (b *FileBackend) List(_ context.Context, prefix, pattern string) ([]*pluginv1.StorageEntry, error) {
+	dir, err := b.resolve(prefix)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []*pluginv1.StorageEntry
+	err = filepath.WalkDir(dir, func(full string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if pattern != "" {
+			if ok, _ := filepath.Match(pattern, d.Name()); !ok {
+				return nil
+			}
+		}
+		rel, err := filepath.Rel(b.root, full)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry := &pluginv1.StorageEntry{
+			Path:       rel,
+			Size:       info.Size(),
+			ModifiedAt: timestamppb.New(info.ModTime()),
+		}
+		if resp, err := readEntry(full, rel); err == nil {
+			entry.Version = resp.Version
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// resolve maps a storage path to a file under the root, rejecting paths that
+// would escape it.
+func (b *FileBackend) resolve(path string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(path))
+	if clean == "." {
+		return b.root, nil
+	}
+	if !filepath.IsLocal(clean) {
+		return "", fmt.Errorf("invalid storage path: %s", path)
+	}
+	return filepath.Join(b.root, clean), nil
+}
+
+// readEntry reads and decodes a single storage file.
+func readEntry(full, path string) (*pluginv1.StorageReadResponse, error) {
+	data, err := os.ReadFile(full)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, path)
+		}
+		return nil, err
+	}
+	resp, err := decodeEntry(data)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return resp, nil
+}
+
+// encodeEntry renders metadata, version, and content as a markdown file with
+// YAML frontmatter.
+func encodeEntry(metadata *structpb.Struct, content []byte, version int64) ([]byte, error) {
+	fields := make(map[string]any)
+	if metadata != nil {
+		for k, v := range metadata.AsMap() {
+			fields[k] = v
+		}
+	}
+	fields[versionKey] = version
+
+	front, err := yaml.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(front)
+	buf.WriteString("---\n")
+	buf.Write(content)
+	return buf.Bytes(), nil
+}
+
+// decodeEntry parses a file written by encodeEntry. Files without frontmatter
+// are treated as plain content at version 1.
+func decodeEntry(data []byte) (*pluginv1.StorageReadResponse, error) {
+	text := string(data)
+	if !strings.HasPrefix(text, "---\n") {
+		return &pluginv1.StorageReadResponse{Content: data, Version: 1}, nil
+	}
+	rest := text[len("---\n"):]
+	end := strings.Index(rest, "\n---\n")
+	var front, body string
+	switch {
+	case strings.HasPrefix(rest, "---\n"):
+		body = rest[len("---\n"):]
+	case end >= 0:
+		front, body = rest[:end+1], rest[end+len("\n---\n"):]
+	default:
+		return nil, fmt.Errorf("unterminated frontmatter")
+	}
+
+	fields := make(map[string]any)
+	if err := yaml.Unmarshal([]byte(front), &fields); err != nil {
+		return nil, fmt.Errorf("parse frontmatter: %w", err)
+	}
+
+	var version int64 = 1
+	if v, ok := fields[versionKey]; ok {
+		if n, ok := v.(int); ok {
+			version = int64(n)
+		}
+		delete(fields, versionKey)
+	}
+
+	resp := &pluginv1.StorageReadResponse{Version: version}
+	if body != "" {
+		resp.Content = []byte(body)
+	}
+	if len(fields) > 0 {
+		meta, err := structpb.NewStruct(fields)
+		if err != nil {
+			return nil, fmt.Errorf("convert frontmatter: %w", err)
+		}
+		resp.Metadata = meta
+	}
+	return resp, nil
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it into
+// place, so readers never observe a partially written entry.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,180 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestFileBackend_WriteRead(t *testing.T) {
+	b := NewFileBackend(t.TempDir())
+	ctx := context.Background()
+
+	meta, _ := structpb.NewStruct(map[string]any{
+		"name":       "Go Backend",
+		"created_at": "2026-02-27T09:21:36Z",
+		"tags":       []any{"go", "api"},
+	})
+	version, err := b.Write(ctx, ".skills/go-backend.md", meta, []byte("# Go Backend\n"), 0)
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if version != 1 {
+		t.Errorf("expected version 1, got %d", version)
+	}
+
+	resp, err := b.Read(ctx, ".skills/go-backend.md")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if resp.Version != 1 {
+		t.Errorf("expected version 1, got %d", resp.Version)
+	}
+	if string(resp.Content) != "# Go Backend\n" {
+		t.Errorf("unexpected content: %q", resp.Content)
+	}
+	m := resp.Metadata.AsMap()
+	if m["name"] != "Go Backend" {
+		t.Errorf("unexpected name: %v", m["name"])
+	}
+	if m["created_at"] != "2026-02-27T09:21:36Z" {
+		t.Errorf("unexpected created_at: %v", m["created_at"])
+	}
+	if _, ok := m[versionKey]; ok {
+		t.Error("version key should not leak into metadata")
+	}
+}
+
+func TestFileBackend_ReadMissing(t *testing.T) {
+	b := NewFileBackend(t.TempDir())
+	_, err := b.Read(context.Background(), ".skills/missing.md")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestFileBackend_OptimisticVersion(t *testing.T) {
+	b := NewFileBackend(t.TempDir())
+	ctx := context.Background()
+
+	if _, err := b.Write(ctx, "a.md", nil, []byte("one"), 0); err != nil {
+		t.Fatal(err)
+	}
+	// Creating again must conflict.
+	if _, err := b.Write(ctx, "a.md", nil, []byte("two"), 0); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("expected conflict on re-create, got %v", err)
+	}
+	// Stale version must conflict.
+	if _, err := b.Write(ctx, "a.md", nil, []byte("two"), 5); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("expected conflict on stale version, got %v", err)
+	}
+	v, err := b.Write(ctx, "a.md", nil, []byte("two"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v != 2 {
+		t.Errorf("expected version 2, got %d", v)
+	}
+}
+
+func TestFileBackend_RejectsEscapingPath(t *testing.T) {
+	b := NewFileBackend(t.TempDir())
+	if _, err := b.Write(context.Background(), "../outside.md", nil, nil, 0); err == nil {
+		t.Error("expected error for path escaping root")
+	}
+}
+
+func TestFileBackend_DeleteAndList(t *testing.T) {
+	root := t.TempDir()
+	b := NewFileBackend(root)
+	ctx := context.Background()
+
+	b.Write(ctx, ".skills/a.md", nil, []byte("a"), 0)
+	b.Write(ctx, ".skills/b.md", nil, []byte("b"), 0)
+	b.Write(ctx, ".agents/c.md", nil, []byte("c"), 0)
+
+	entries, err := b.List(ctx, ".skills/", "*.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Path, ".skills/") {
+			t.Errorf("unexpected path %q", e.Path)
+		}
+		if e.Version != 1 {
+			t.Errorf("expected version 1 for %s, got %d", e.Path, e.Version)
+		}
+	}
+
+	if err := b.Delete(ctx, ".skills/a.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(root, ".skills", "a.md")); !os.IsNotExist(err) {
+		t.Error("file should be removed")
+	}
+	if err := b.Delete(ctx, ".skills/a.md"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound on second delete, got %v", err)
+	}
+
+	// Listing a prefix that doesn't exist yet is empty, not an error.
+	entries, err = b.List(ctx, ".hooks/", "")
+	if err != nil || len(entries) != 0 {
+		t.Errorf("expected empty listing, got %v, %v", entries, err)
+	}
+}
+
+func TestPackStorage_FileBackendRegistryRoundTrip(t *testing.T) {
+	ps := NewPackStorageWithBackend(NewFileBackend(t.TempDir()))
+	ctx := context.Background()
+
+	reg, version, err := ps.ReadRegistry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 0 || len(reg.Packs) != 0 {
+		t.Fatalf("expected empty registry at version 0, got %d packs at %d", len(reg.Packs), version)
+	}
+
+	reg.Packs["orchestra-mcp/pack-go-backend"] = &PackEntry{
+		Version: "0.3.0",
+		Repo:    "github.com/orchestra-mcp/pack-go-backend",
+		Stacks:  []string{"go"},
+		Skills:  []string{"go-backend"},
+	}
+	newVersion, err := ps.WriteRegistry(ctx, reg, version)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, gotVersion, err := ps.ReadRegistry(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gotVersion != newVersion {
+		t.Errorf("expected version %d, got %d", newVersion, gotVersion)
+	}
+	entry := got.Packs["orchestra-mcp/pack-go-backend"]
+	if entry == nil || entry.Version != "0.3.0" || len(entry.Skills) != 1 {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+
+	// Writing with the stale version must fail.
+	if _, err := ps.WriteRegistry(ctx, reg, version); err == nil {
+		t.Error("expected conflict writing with stale version")
+	}
+}
+
+func TestPackStorage_SendRequiresClientBackend(t *testing.T) {
+	ps := NewPackStorageWithBackend(NewFileBackend(t.TempDir()))
+	if _, err := ps.Send(context.Background(), nil); err == nil {
+		t.Error("expected error from Send on a file backend")
+	}
+}
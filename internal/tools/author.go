@@ -0,0 +1,349 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
+	"github.com/orchestra-mcp/sdk-go/helpers"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// --- lint_pack ---
+
+func LintPackSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{"type": "string", "description": "Pack directory to lint (absolute, or relative to the workspace)"},
+		},
+		"required": []any{"path"},
+	})
+	return s
+}
+
+func LintPack(workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "path"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+
+		dir := helpers.GetString(req.Arguments, "path")
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(workspace, dir)
+		}
+
+		report, err := packs.LintPack(dir)
+		if err != nil {
+			return helpers.ErrorResult("not_found", err.Error()), nil
+		}
+		return helpers.TextResult(formatLintReport(report)), nil
+	}
+}
+
+// --- create_pack ---
+
+func CreatePackSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":        map[string]any{"type": "string", "description": "Pack name as owner/slug (e.g., 'acme/pack-go-tools')"},
+			"description": map[string]any{"type": "string", "description": "One-line pack description (optional)"},
+			"path":        map[string]any{"type": "string", "description": "Directory to create, relative to the workspace (optional, defaults to the pack slug)"},
+			"stacks":      map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Technology stacks the pack targets (e.g., ['go'])"},
+			"tags":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Search tags"},
+			"include":     map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": []any{"skills", "agents", "hooks", "workflows"}}, "description": "Content types to generate examples for (default: skills, agents)"},
+			"license":     map[string]any{"type": "string", "description": "SPDX license identifier (optional, e.g., 'MIT')"},
+		},
+		"required": []any{"name"},
+	})
+	return s
+}
+
+func CreatePack(workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+
+		name := helpers.GetString(req.Arguments, "name")
+		dir := helpers.GetString(req.Arguments, "path")
+		if dir == "" {
+			_, repo, _ := strings.Cut(name, "/")
+			dir = repo
+		}
+		dir, err := packs.WorkspacePath(workspace, dir)
+		if err != nil {
+			return helpers.ErrorResult("validation_error", "path: "+err.Error()), nil
+		}
+
+		manifest, files, err := packs.ScaffoldPack(dir, packs.ScaffoldOptions{
+			Name:        name,
+			Description: helpers.GetString(req.Arguments, "description"),
+			License:     helpers.GetString(req.Arguments, "license"),
+			Stacks:      helpers.GetStringSlice(req.Arguments, "stacks"),
+			Tags:        helpers.GetStringSlice(req.Arguments, "tags"),
+			Include:     helpers.GetStringSlice(req.Arguments, "include"),
+		})
+		if err != nil {
+			return helpers.ErrorResult("create_error", err.Error()), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Created: %s\n\n", manifest.Name)
+		fmt.Fprintf(&b, "- **Path:** %s\n", dir)
+		fmt.Fprintf(&b, "- **Version:** %s\n\n", manifest.Version)
+		fmt.Fprintf(&b, "### Files\n\n")
+		for _, f := range files {
+			fmt.Fprintf(&b, "- `%s`\n", f)
+		}
+		if report, err := packs.LintPack(dir); err == nil {
+			fmt.Fprintf(&b, "\nLint: %d error(s), %d warning(s). ", report.Count(packs.SeverityError), report.Count(packs.SeverityWarning))
+		}
+		fmt.Fprintf(&b, "Edit the example content, then run `lint_pack` before publishing.")
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// --- export_pack ---
+
+func ExportPackSchema() *structpb.Struct {
+	patterns := func(kind string) map[string]any {
+		return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": kind + " to export, by slug or glob (e.g., 'go-*')"}
+	}
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":                map[string]any{"type": "string", "description": "Pack name as owner/slug (e.g., 'acme/pack-team-tools')"},
+			"description":         map[string]any{"type": "string", "description": "One-line pack description (optional)"},
+			"path":                map[string]any{"type": "string", "description": "Output directory, or archive file with format=archive, relative to the workspace (optional, defaults to the pack slug)"},
+			"format":              map[string]any{"type": "string", "enum": []any{"directory", "archive"}, "description": "Write a pack directory or a .tar.gz archive (default: directory)"},
+			"skills":              patterns("Skills"),
+			"agents":              patterns("Agents"),
+			"hooks":               patterns("Hooks"),
+			"workflows":           patterns("Workflow files"),
+			"packs":               map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Installed packs whose content to export"},
+			"include_third_party": map[string]any{"type": "boolean", "description": "Also export content installed from packs not listed in packs (default false)"},
+			"version":             map[string]any{"type": "string", "description": "Version to write (optional, default 0.1.0 or the previous export's version)"},
+			"bump":                map[string]any{"type": "string", "enum": []any{"major", "minor", "patch"}, "description": "Bump the version of a previous export in the same directory"},
+			"license":             map[string]any{"type": "string", "description": "SPDX license identifier (optional)"},
+			"stacks":              map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Technology stacks the pack targets"},
+			"tags":                map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Search tags"},
+		},
+		"required": []any{"name"},
+	})
+	return s
+}
+
+func ExportPack(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+
+		name := helpers.GetString(req.Arguments, "name")
+		archive := helpers.GetString(req.Arguments, "format") == "archive"
+		_, repo, _ := strings.Cut(name, "/")
+		out := helpers.GetString(req.Arguments, "path")
+		if out == "" {
+			out = repo
+			if archive {
+				out += ".tar.gz"
+			}
+		}
+		out, err := packs.WorkspacePath(workspace, out)
+		if err != nil {
+			return helpers.ErrorResult("validation_error", "path: "+err.Error()), nil
+		}
+
+		reg, _, err := ps.ReadRegistry(ctx)
+		if err != nil {
+			return registryError(err), nil
+		}
+		stored, err := storedExportItems(ctx, ps)
+		if err != nil {
+			return helpers.ErrorResult("storage_error", err.Error()), nil
+		}
+		items := packs.MergeExportItems(packs.CollectWorkspaceContent(workspace), stored)
+		owners := contentOwners(reg)
+		for i := range items {
+			items[i].Pack = owners[items[i].Key()]
+		}
+
+		selected, skipped, err := packs.SelectExport(items, packs.ExportSelection{
+			Patterns: map[string][]string{
+				packs.ContentSkills:    helpers.GetStringSlice(req.Arguments, "skills"),
+				packs.ContentAgents:    helpers.GetStringSlice(req.Arguments, "agents"),
+				packs.ContentHooks:     helpers.GetStringSlice(req.Arguments, "hooks"),
+				packs.ContentWorkflows: helpers.GetStringSlice(req.Arguments, "workflows"),
+			},
+			Packs:             helpers.GetStringSlice(req.Arguments, "packs"),
+			IncludeThirdParty: helpers.GetBool(req.Arguments, "include_third_party"),
+		})
+		if err != nil {
+			return helpers.ErrorResult("not_found", err.Error()), nil
+		}
+
+		dir := out
+		if archive {
+			tmp, err := os.MkdirTemp("", "orchestra-export-*")
+			if err != nil {
+				return helpers.ErrorResult("export_error", err.Error()), nil
+			}
+			defer os.RemoveAll(tmp)
+			dir = filepath.Join(tmp, repo)
+		}
+		opts := packs.ExportOptions{
+			Name:        name,
+			Description: helpers.GetString(req.Arguments, "description"),
+			License:     helpers.GetString(req.Arguments, "license"),
+			Stacks:      helpers.GetStringSlice(req.Arguments, "stacks"),
+			Tags:        helpers.GetStringSlice(req.Arguments, "tags"),
+			Version:     helpers.GetString(req.Arguments, "version"),
+			Bump:        helpers.GetString(req.Arguments, "bump"),
+			HookEvents:  exportHookEvents(workspace),
+		}
+		manifest, files, err := packs.ExportPack(dir, selected, opts)
+		if err != nil {
+			return helpers.ErrorResult("export_error", err.Error()), nil
+		}
+		if archive {
+			if err := packs.ArchivePack(dir, out); err != nil {
+				return helpers.ErrorResult("export_error", err.Error()), nil
+			}
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Exported: %s\n\n", manifest.Name)
+		if archive {
+			fmt.Fprintf(&b, "- **Archive:** %s\n", out)
+		} else {
+			fmt.Fprintf(&b, "- **Path:** %s\n", out)
+		}
+		fmt.Fprintf(&b, "- **Version:** %s\n", manifest.Version)
+		fmt.Fprintf(&b, "- **Files:** %d\n\n", len(files))
+		fmt.Fprintf(&b, "| Type | Name | Source |\n")
+		fmt.Fprintf(&b, "|------|------|--------|\n")
+		for _, it := range selected {
+			source := it.Source
+			if it.Pack != "" {
+				source += " (from " + it.Pack + ")"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", strings.TrimSuffix(it.Type, "s"), it.Slug, source)
+		}
+		if len(skipped) > 0 {
+			fmt.Fprintf(&b, "\n### Skipped\n\n")
+			for _, s := range skipped {
+				fmt.Fprintf(&b, "- %s `%s`: %s\n", strings.TrimSuffix(s.Item.Type, "s"), s.Item.Slug, s.Reason)
+			}
+		}
+		if !archive {
+			if report, err := packs.LintPack(dir); err == nil {
+				fmt.Fprintf(&b, "\nLint: %d error(s), %d warning(s). Run `lint_pack` for details.", report.Count(packs.SeverityError), report.Count(packs.SeverityWarning))
+			}
+		}
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// storedExportItems reads skills, agents, and hooks created through the CRUD
+// tools from storage.
+func storedExportItems(ctx context.Context, ps *storage.PackStorage) ([]packs.ExportItem, error) {
+	var items []packs.ExportItem
+	for _, kind := range []struct{ prefix, contentType string }{
+		{".skills", packs.ContentSkills},
+		{".agents", packs.ContentAgents},
+		{".hooks", packs.ContentHooks},
+	} {
+		entries, err := ps.StorageList(ctx, kind.prefix, "*.md")
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", kind.prefix, err)
+		}
+		for _, e := range entries {
+			resp, err := ps.StorageRead(ctx, e.Path)
+			if err != nil {
+				continue
+			}
+			slug := strings.TrimSuffix(path.Base(e.Path), ".md")
+			items = append(items, packs.StoredItem(kind.contentType, slug, resp.Metadata.AsMap(), resp.Content))
+		}
+	}
+	return items, nil
+}
+
+// contentOwners maps each installed pack's content ("skills/<slug>") to the
+// pack that provided it.
+func contentOwners(reg *storage.PackRegistry) map[string]string {
+	owners := make(map[string]string)
+	for name, entry := range reg.Packs {
+		for contentType, list := range map[string][]string{
+			packs.ContentSkills:    entry.Skills,
+			packs.ContentAgents:    entry.Agents,
+			packs.ContentHooks:     entry.Hooks,
+			packs.ContentWorkflows: entry.Workflows,
+		} {
+			for _, slug := range list {
+				owners[contentType+"/"+slug] = name
+			}
+		}
+	}
+	return owners
+}
+
+// formatLintReport renders lint findings as a markdown table with totals.
+func formatLintReport(report *packs.LintReport) string {
+	name := filepath.Base(report.Dir)
+	if report.Manifest != nil {
+		name = report.Manifest.Name
+	}
+	errs, warns, infos := report.Count(packs.SeverityError), report.Count(packs.SeverityWarning), report.Count(packs.SeverityInfo)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Lint: %s\n\n", name)
+	if len(report.Issues) == 0 {
+		fmt.Fprintf(&b, "No issues found. The pack is ready to publish.")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "| Severity | Location | Rule | Message |\n")
+	fmt.Fprintf(&b, "|----------|----------|------|---------|\n")
+	for _, issue := range report.Issues {
+		fmt.Fprintf(&b, "| %s | `%s` | %s | %s |\n",
+			issue.Severity, issue.Location(), issue.Rule, strings.ReplaceAll(issue.Message, "|", "\\|"))
+	}
+	fmt.Fprintf(&b, "\n%d error(s), %d warning(s), %d info.", errs, warns, infos)
+	if errs > 0 {
+		fmt.Fprintf(&b, " Errors must be fixed before the pack can be installed or published.")
+	}
+	return b.String()
+}
+
+// exportHookEvents reads how workspace hooks are wired in settings.json, so an
+// export declares the same events. A hook wired to several events keeps the
+// first; the rest must be added to pack.json by hand.
+func exportHookEvents(workspace string) map[string]packs.HookEvent {
+	wiring, _ := packs.ReadHookWiring(workspace)
+	events := make(map[string]packs.HookEvent, len(wiring))
+	for name, entries := range wiring {
+		entries = slices.DeleteFunc(entries, func(w packs.HookWiring) bool {
+			return !slices.Contains(packs.KnownHookEvents, w.Event)
+		})
+		if len(entries) == 0 {
+			continue
+		}
+		slices.SortFunc(entries, func(a, b packs.HookWiring) int {
+			return cmp.Or(cmp.Compare(a.Event, b.Event), cmp.Compare(a.Matcher, b.Matcher))
+		})
+		w := entries[0]
+		events[name] = packs.HookEvent{Event: w.Event, Matcher: w.Matcher, Timeout: min(w.Timeout, packs.MaxHookTimeout)}
+	}
+	return events
+}
@@ -82,3 +82,60 @@ func GetProjectStacks(ps *storage.PackStorage, workspace string) ToolHandler {
 		return helpers.TextResult(b.String()), nil
 	}
 }
+
+// --- set_trusted_keys ---
+
+func SetTrustedKeysSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"prefix": map[string]any{"type": "string", "description": "Repo prefix the keys apply to (e.g., 'github.com/acme' or 'github.com/acme/pack-go'), or '*' for every repo"},
+			"keys": map[string]any{
+				"type":        "array",
+				"items":       map[string]any{"type": "string"},
+				"description": "Minisign public keys (the 'RW...' line of minisign.pub). Pass an empty list to stop requiring signatures for the prefix.",
+			},
+		},
+		"required": []any{"prefix", "keys"},
+	})
+	return s
+}
+
+func SetTrustedKeys(ps *storage.PackStorage) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "prefix"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+		prefix := strings.TrimSuffix(helpers.GetString(req.Arguments, "prefix"), "/")
+		keys := helpers.GetStringSlice(req.Arguments, "keys")
+
+		var ids []string
+		for i, k := range keys {
+			pk, err := packs.ParsePublicKey(k)
+			if err != nil {
+				return helpers.ErrorResult("validation_error", fmt.Sprintf("keys[%d]: %v", i, err)), nil
+			}
+			keys[i] = packs.FormatPublicKey(pk)
+			ids = append(ids, pk.KeyID())
+		}
+
+		if _, err := ps.UpdateRegistry(ctx, func(reg *storage.PackRegistry) error {
+			if len(keys) == 0 {
+				delete(reg.TrustedKeys, prefix)
+				return nil
+			}
+			if reg.TrustedKeys == nil {
+				reg.TrustedKeys = make(map[string][]string)
+			}
+			reg.TrustedKeys[prefix] = keys
+			return nil
+		}); err != nil {
+			return registryError(err), nil
+		}
+
+		if len(keys) == 0 {
+			return helpers.TextResult(fmt.Sprintf("Removed trusted keys for %s. Packs from it no longer need a signature.", prefix)), nil
+		}
+		return helpers.TextResult(fmt.Sprintf("## Trusted Keys: %s\n\n- **Keys:** %s\n\nPacks from %s must now be signed by one of these keys to install or update.", prefix, strings.Join(ids, ", "), prefix)), nil
+	}
+}
@@ -1,39 +1,45 @@
 package tools
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
 	"github.com/orchestra-mcp/sdk-go/helpers"
+	"github.com/orchestra-mcp/sdk-go/workflow"
 	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
 	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // --- list_skills ---
 
 func ListSkillsSchema() *structpb.Struct {
-	s, _ := structpb.NewStruct(map[string]any{
-		"type":       "object",
-		"properties": map[string]any{},
-	})
-	return s
+	return listContentSchema("skills")
 }
 
-func ListSkills(workspace string) ToolHandler {
+func ListSkills(ps *storage.PackStorage, workspace string) ToolHandler {
 	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
-		names := packs.ListInstalledSkills(workspace)
-
-		if len(names) == 0 {
-			return helpers.TextResult("## Installed Skills\n\nNo skills found. Use `install_pack` to add skill packs."), nil
+		l, errResp := queryContent(ctx, ps, workspace, packs.ContentSkills, req.Arguments)
+		if errResp != nil {
+			return errResp, nil
+		}
+		if l.total == 0 {
+			return helpers.TextResult(l.empty("Skills", "skill")), nil
 		}
 
 		var b strings.Builder
-		fmt.Fprintf(&b, "## Installed Skills (%d)\n\n", len(names))
-		for _, name := range names {
-			fmt.Fprintf(&b, "- `%s`\n", name)
-		}
+		fmt.Fprintf(&b, "## Skills (%d)\n\n", l.total)
+		l.writeTable(&b, "Skill", nil)
+		l.writeFooter(&b, "skill")
 		return helpers.TextResult(b.String()), nil
 	}
 }
@@ -41,57 +47,308 @@ func ListSkills(workspace string) ToolHandler {
 // --- list_agents ---
 
 func ListAgentsSchema() *structpb.Struct {
+	return listContentSchema("agents")
+}
+
+func ListAgents(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		l, errResp := queryContent(ctx, ps, workspace, packs.ContentAgents, req.Arguments)
+		if errResp != nil {
+			return errResp, nil
+		}
+		if l.total == 0 {
+			return helpers.TextResult(l.empty("Agents", "agent")), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Agents (%d)\n\n", l.total)
+		l.writeTable(&b, "Agent", nil)
+		l.writeFooter(&b, "agent")
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// listContentSchema is the schema of list_skills, list_agents, and
+// list_hooks.
+func listContentSchema(kind string) *structpb.Struct {
+	sorts := make([]any, len(packs.ContentSorts))
+	for i, by := range packs.ContentSorts {
+		sorts[i] = by
+	}
 	s, _ := structpb.NewStruct(map[string]any{
-		"type":       "object",
-		"properties": map[string]any{},
+		"type": "object",
+		"properties": map[string]any{
+			"pack":    map[string]any{"type": "string", "description": "Only list " + kind + " installed by this pack (optional)"},
+			"scope":   map[string]any{"type": "string", "description": "Only list " + kind + " with this scope, e.g. global, project, or workspace for files not in storage (optional)"},
+			"tag":     map[string]any{"type": "string", "description": "Only list " + kind + " with this tag (optional)"},
+			"query":   map[string]any{"type": "string", "description": "Only list " + kind + " whose slug, name, or description contains this text (optional)"},
+			"sort":    map[string]any{"type": "string", "description": "Sort by name (default), modified, size, pack, or scope", "enum": sorts},
+			"reverse": map[string]any{"type": "boolean", "description": "Reverse the sort order (default: false)"},
+			"limit":   map[string]any{"type": "number", "description": "Maximum entries to return (default 50, max 200)"},
+			"offset":  map[string]any{"type": "number", "description": "Entries to skip (default 0)"},
+		},
 	})
 	return s
 }
 
-func ListAgents(workspace string) ToolHandler {
-	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
-		names := packs.ListInstalledAgents(workspace)
+// contentListing is one page of the merged workspace and storage content of
+// a kind, after filtering and sorting.
+type contentListing struct {
+	page       []packs.ContentEntry
+	total      int // entries that passed the filter
+	offset     int
+	differs    []string // slugs that passed the filter and differ between files and storage
+	filtered   bool
+	stored     map[string]*packs.SyncStored
+	storageErr error
+}
+
+// queryContent merges the workspace files and storage entries of a kind and
+// applies the filter, sort, and pagination arguments of a list tool. Storage
+// that cannot be read is reported with the listing rather than failing it.
+func queryContent(ctx context.Context, ps *storage.PackStorage, workspace, kind string, args *structpb.Struct) (*contentListing, *pluginv1.ToolResponse) {
+	filter := packs.ContentFilter{
+		Pack:  helpers.GetString(args, "pack"),
+		Scope: helpers.GetString(args, "scope"),
+		Tag:   helpers.GetString(args, "tag"),
+		Text:  helpers.GetString(args, "query"),
+	}
+	sortBy := helpers.GetString(args, "sort")
+	if sortBy != "" && !slices.Contains(packs.ContentSorts, sortBy) {
+		return nil, helpers.ErrorResult("validation_error", fmt.Sprintf("invalid sort %q (want one of %s)", sortBy, strings.Join(packs.ContentSorts, ", ")))
+	}
 
-		if len(names) == 0 {
-			return helpers.TextResult("## Installed Agents\n\nNo agents found. Use `install_pack` to add agent packs."), nil
+	files := make(map[string]*packs.SyncFile)
+	for key, f := range packs.ScanSyncFiles(workspace, []string{kind}) {
+		files[strings.TrimPrefix(key, kind+"/")] = f
+	}
+	l := &contentListing{filtered: filter != packs.ContentFilter{}}
+	l.stored, l.storageErr = readStoredContent(ctx, ps, kind)
+	entries := packs.ContentEntries(kind, files, l.stored, workspaceProvenance(ctx, ps, workspace))
+
+	entries = slices.DeleteFunc(entries, func(e packs.ContentEntry) bool { return !filter.Match(e) })
+	if err := packs.SortContent(entries, sortBy, helpers.GetBool(args, "reverse")); err != nil {
+		return nil, helpers.ErrorResult("validation_error", err.Error())
+	}
+	for _, e := range entries {
+		if e.Differs {
+			l.differs = append(l.differs, e.Slug)
 		}
+	}
+	slices.Sort(l.differs)
+	p := helpers.ParsePagination(args)
+	l.total, l.offset = len(entries), p.Offset
+	l.page = helpers.PaginateSlice(entries, p)
+	return l, nil
+}
 
-		var b strings.Builder
-		fmt.Fprintf(&b, "## Installed Agents (%d)\n\n", len(names))
-		for _, name := range names {
-			fmt.Fprintf(&b, "- `%s`\n", name)
+// empty renders the result of a listing with no entries.
+func (l *contentListing) empty(title, noun string) string {
+	msg := fmt.Sprintf("No %ss found. Use `install_pack` to add %s packs, or `create_%s`.", noun, noun, noun)
+	if l.filtered {
+		msg = fmt.Sprintf("No %ss match the filters.", noun)
+	}
+	if l.storageErr != nil {
+		msg += fmt.Sprintf("\n\nCould not read storage: %v", l.storageErr)
+	}
+	return fmt.Sprintf("## %s\n\n%s", title, msg)
+}
+
+// writeTable renders the page as a table, with extra columns from extra
+// when it is set. An empty page renders nothing.
+func (l *contentListing) writeTable(b *strings.Builder, label string, extra func(e packs.ContentEntry) []string, extraHeaders ...string) {
+	if len(l.page) == 0 {
+		return
+	}
+	headers := append([]string{label, "Description", "Scope", "Pack", "Source", "Size", "Last modified", "Tags"}, extraHeaders...)
+	fmt.Fprintf(b, "| %s |\n|%s\n", strings.Join(headers, " | "), strings.Repeat("---|", len(headers)))
+	for _, e := range l.page {
+		name := "`" + e.Slug + "`"
+		if e.Name != e.Slug {
+			name += " (" + e.Name + ")"
 		}
-		return helpers.TextResult(b.String()), nil
+		modified := "-"
+		if !e.ModTime.IsZero() {
+			modified = e.ModTime.UTC().Format("2006-01-02 15:04")
+		}
+		cells := []string{name, describeEntry(e), e.Scope, entryPack(e), entrySource(e), packs.FormatSize(int64(e.Size)), modified, orDash(strings.Join(e.Tags, ", "))}
+		if extra != nil {
+			cells = append(cells, extra(e)...)
+		}
+		for i, c := range cells {
+			cells[i] = strings.ReplaceAll(c, "|", "\\|")
+		}
+		fmt.Fprintf(b, "| %s |\n", strings.Join(cells, " | "))
 	}
 }
 
+// writeFooter notes content that differs between files and storage, the
+// rest of a partial page, and unreadable storage.
+func (l *contentListing) writeFooter(b *strings.Builder, noun string) {
+	if len(l.differs) > 0 {
+		fmt.Fprintf(b, "\n%d %s(s) in both .claude/ and storage with different content: %s. Run `sync_content` to reconcile them.\n", len(l.differs), noun, strings.Join(l.differs, ", "))
+	}
+	switch {
+	case len(l.page) == 0 && l.total > 0:
+		fmt.Fprintf(b, "\nOffset %d is past the last of %d entries.\n", l.offset, l.total)
+	case len(l.page) < l.total:
+		fmt.Fprintf(b, "\nShowing %d-%d of %d. Use `offset` for more.\n", l.offset+1, l.offset+len(l.page), l.total)
+	}
+	if l.storageErr != nil {
+		fmt.Fprintf(b, "\nCould not read storage, only .claude/ is listed: %v\n", l.storageErr)
+	}
+}
+
+// describeEntry renders a list entry's description and its model and tool
+// settings.
+func describeEntry(e packs.ContentEntry) string {
+	s := orDash(e.Description)
+	var details []string
+	if e.Model != "" {
+		details = append(details, "model: "+e.Model)
+	}
+	if len(e.Tools) > 0 {
+		details = append(details, "tools: "+strings.Join(e.Tools, ", "))
+	}
+	if len(details) > 0 {
+		s += " (" + strings.Join(details, "; ") + ")"
+	}
+	return s
+}
+
+// entryPack renders the pack that owns a list entry, with its version and
+// whether the files were modified when the workspace copy came from it.
+func entryPack(e packs.ContentEntry) string {
+	if e.Pack == "" {
+		return "-"
+	}
+	s := "`" + e.Pack + "`"
+	if p := e.Provenance; p != nil {
+		if p.Version != "" {
+			s += " v" + p.Version
+		}
+		if p.Modified {
+			s += ", modified"
+		}
+	}
+	return s
+}
+
+// entrySource renders where a list entry exists.
+func entrySource(e packs.ContentEntry) string {
+	switch {
+	case e.Differs:
+		return "both, **differs**"
+	case e.InFiles && e.InStorage:
+		return "both"
+	case e.InFiles:
+		return "files"
+	}
+	return "storage"
+}
+
+// workspaceProvenance returns the provenance of the workspace's installed
+// content, from .claude/.provenance.json alone when the registry can't be
+// read.
+func workspaceProvenance(ctx context.Context, ps *storage.PackStorage, workspace string) map[string]*packs.Provenance {
+	reg, _, err := ps.ReadRegistry(ctx)
+	if err != nil {
+		reg = &storage.PackRegistry{}
+	}
+	return contentProvenance(workspace, reg)
+}
+
+// withProvenance appends a note on where pack-owned content came from, for
+// get_skill and get_agent.
+func withProvenance(content string, p *packs.Provenance) string {
+	if p == nil {
+		return content
+	}
+	return fmt.Sprintf("%s\n\n> Installed by %s. `update_pack %s` replaces local edits.\n", strings.TrimRight(content, "\n"), p.String(), p.Pack)
+}
+
 // --- list_hooks ---
 
 func ListHooksSchema() *structpb.Struct {
-	s, _ := structpb.NewStruct(map[string]any{
-		"type":       "object",
-		"properties": map[string]any{},
-	})
-	return s
+	return listContentSchema("hooks")
 }
 
-func ListHooks(workspace string) ToolHandler {
+func ListHooks(ps *storage.PackStorage, workspace string) ToolHandler {
 	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
-		names := packs.ListInstalledHooks(workspace)
+		l, errResp := queryContent(ctx, ps, workspace, packs.ContentHooks, req.Arguments)
+		if errResp != nil {
+			return errResp, nil
+		}
+		pending := packs.ListPendingHooks(workspace)
+		if pack := helpers.GetString(req.Arguments, "pack"); pack != "" {
+			prov := workspaceProvenance(ctx, ps, workspace)
+			pending = slices.DeleteFunc(pending, func(name string) bool {
+				p := prov[packs.SyncKey(packs.ContentHooks, name)]
+				return p == nil || p.Pack != pack
+			})
+		}
 
-		if len(names) == 0 {
-			return helpers.TextResult("## Installed Hooks\n\nNo hooks found. Use `install_pack` to add hook packs."), nil
+		if l.total == 0 && len(pending) == 0 {
+			return helpers.TextResult(l.empty("Hooks", "hook")), nil
 		}
 
+		wiring, wiringErr := packs.ReadHookWiring(workspace)
+		installed := packs.ListInstalledHooks(workspace)
+
 		var b strings.Builder
-		fmt.Fprintf(&b, "## Installed Hooks (%d)\n\n", len(names))
-		for _, name := range names {
-			fmt.Fprintf(&b, "- `%s`\n", name)
+		fmt.Fprintf(&b, "## Hooks (%d)\n\n", l.total)
+		if l.total > 0 {
+			l.writeTable(&b, "Hook", func(e packs.ContentEntry) []string {
+				var scan *packs.HookScan
+				if e.InFiles {
+					scan, _ = packs.ScanHookFile(packs.HookPath(workspace, e.Slug))
+				} else if s := l.stored[e.Slug]; s != nil {
+					scan = packs.ScanHook(s.Body)
+				}
+				risk := "unreadable"
+				if scan != nil {
+					risk = scan.Summary()
+				}
+				return []string{describeWiring(wiring[e.Slug]), risk}
+			}, "Wiring", "Risk")
+		}
+		l.writeFooter(&b, "hook")
+		if len(pending) > 0 {
+			fmt.Fprintf(&b, "\n%d hook(s) pending review: %s. See `review_hooks`.\n", len(pending), strings.Join(pending, ", "))
+		}
+		var dangling []string
+		for _, name := range slices.Sorted(maps.Keys(wiring)) {
+			if !l.filtered && !slices.Contains(installed, name) {
+				dangling = append(dangling, name)
+			}
 		}
+		if len(dangling) > 0 {
+			fmt.Fprintf(&b, "\nWired in settings.json but not installed: %s.\n", strings.Join(dangling, ", "))
+		}
+		if wiringErr != nil {
+			fmt.Fprintf(&b, "\nCould not read hook wiring: %v\n", wiringErr)
+		}
+		fmt.Fprintf(&b, "\nRun `audit_hooks` for the individual findings.")
 		return helpers.TextResult(b.String()), nil
 	}
 }
 
+// describeWiring renders the settings.json entries that run a hook.
+func describeWiring(wiring []packs.HookWiring) string {
+	if len(wiring) == 0 {
+		return "not wired"
+	}
+	parts := make([]string, len(wiring))
+	for i, w := range wiring {
+		parts[i] = describeHookEvent(w.Event, w.Matcher, w.Timeout)
+		if !w.Managed {
+			parts[i] += " (manual)"
+		}
+	}
+	slices.Sort(parts)
+	return "wired: " + strings.Join(parts, "; ")
+}
+
 // --- get_skill ---
 
 func GetSkillSchema() *structpb.Struct {
@@ -105,7 +362,7 @@ func GetSkillSchema() *structpb.Struct {
 	return s
 }
 
-func GetSkill(workspace string) ToolHandler {
+func GetSkill(ps *storage.PackStorage, workspace string) ToolHandler {
 	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
 		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
 			return helpers.ErrorResult("validation_error", err.Error()), nil
@@ -116,8 +373,9 @@ func GetSkill(workspace string) ToolHandler {
 		if err != nil {
 			return helpers.ErrorResult("not_found", err.Error()), nil
 		}
+		prov := workspaceProvenance(ctx, ps, workspace)
 
-		return helpers.TextResult(content), nil
+		return helpers.TextResult(withProvenance(content, prov[packs.SyncKey(packs.ContentSkills, name)])), nil
 	}
 }
 
@@ -134,7 +392,7 @@ func GetAgentSchema() *structpb.Struct {
 	return s
 }
 
-func GetAgent(workspace string) ToolHandler {
+func GetAgent(ps *storage.PackStorage, workspace string) ToolHandler {
 	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
 		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
 			return helpers.ErrorResult("validation_error", err.Error()), nil
@@ -145,7 +403,170 @@ func GetAgent(workspace string) ToolHandler {
 		if err != nil {
 			return helpers.ErrorResult("not_found", err.Error()), nil
 		}
+		prov := workspaceProvenance(ctx, ps, workspace)
+
+		return helpers.TextResult(withProvenance(content, prov[packs.SyncKey(packs.ContentAgents, name)])), nil
+	}
+}
+
+// --- get_hook ---
+
+func GetHookSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "description": "Hook name (filename without .sh under .claude/hooks/)"},
+		},
+		"required": []any{"name"},
+	})
+	return s
+}
+
+func GetHook(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+
+		name := helpers.GetString(req.Arguments, "name")
+		if !packs.ValidSlug(name) {
+			return helpers.ErrorResult("validation_error", fmt.Sprintf("invalid hook name %q", name)), nil
+		}
+
+		var script []byte
+		var source string
+		if data, err := os.ReadFile(packs.HookPath(workspace, name)); err == nil {
+			script, source = data, fmt.Sprintf(".claude/hooks/%s.sh", name)
+		} else if data, err := os.ReadFile(packs.PendingHookPath(workspace, name)); err == nil {
+			script, source = data, fmt.Sprintf(".claude/hooks/.pending/%s.sh (pending review)", name)
+		} else if resp, err := ps.StorageRead(ctx, packs.SyncStoragePath(packs.ContentHooks, name)); err == nil {
+			script, source = resp.Content, "storage only"
+			if ev := helpers.GetString(resp.Metadata, "event_type"); ev != "" {
+				source += " (event: " + ev + ")"
+			}
+		} else {
+			return helpers.ErrorResult("not_found", fmt.Sprintf("hook %q not found in .claude/hooks or storage", name)), nil
+		}
+
+		wiring, _ := packs.ReadHookWiring(workspace)
+		prov := workspaceProvenance(ctx, ps, workspace)
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Hook: %s\n\n", name)
+		fmt.Fprintf(&b, "- **Source:** %s\n", source)
+		fmt.Fprintf(&b, "- **Wiring:** %s\n", describeWiring(wiring[name]))
+		fmt.Fprintf(&b, "- **Risk:** %s\n", packs.ScanHook(script).Summary())
+		if p := prov[packs.SyncKey(packs.ContentHooks, name)]; p != nil {
+			fmt.Fprintf(&b, "- **Pack:** %s\n", p.String())
+		}
+		fmt.Fprintf(&b, "\n```sh\n%s\n```\n", strings.TrimRight(string(script), "\n"))
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// --- list_workflows ---
+
+func ListWorkflowsSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"pack": map[string]any{"type": "string", "description": "Only list workflows installed by this pack (optional)"},
+		},
+	})
+	return s
+}
 
-		return helpers.TextResult(content), nil
+func ListWorkflows(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		pack := helpers.GetString(req.Arguments, "pack")
+		prov := workspaceProvenance(ctx, ps, workspace)
+		files := slices.DeleteFunc(packs.ListInstalledWorkflows(workspace), func(file string) bool {
+			p := prov[workflowKey(file)]
+			return pack != "" && (p == nil || p.Pack != pack)
+		})
+
+		if len(files) == 0 {
+			if pack != "" {
+				return helpers.TextResult(fmt.Sprintf("## Workflows\n\nNo workflows from pack %q.", pack)), nil
+			}
+			return helpers.TextResult("## Workflows\n\nNo workflows found. Use `install_pack` to add workflow packs."), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Workflows (%d)\n\n", len(files))
+		fmt.Fprintf(&b, "| File | Name | Description | Initial state | States | Transitions | Gates | Pack | Applied to |\n")
+		fmt.Fprintf(&b, "|---|---|---|---|---|---|---|---|---|\n")
+		for _, file := range files {
+			packCell := "-"
+			if p := prov[workflowKey(file)]; p != nil {
+				packCell = "`" + p.Pack + "` v" + p.Version
+			}
+			def, err := workflow.LoadFromFile(filepath.Join(workspace, ".claude", "workflows", file))
+			if err != nil {
+				fmt.Fprintf(&b, "| `%s` | - | invalid: %s | - | - | - | - | %s | - |\n", file, strings.ReplaceAll(err.Error(), "|", "\\|"), packCell)
+				continue
+			}
+			fmt.Fprintf(&b, "| `%s` | %s | %s | `%s` | %d | %d | %d | %s | %s |\n",
+				file, orDash(def.Name), orDash(strings.ReplaceAll(def.Description, "|", "\\|")), def.InitialState,
+				len(def.States), len(def.Transitions), len(def.Gates), packCell, orDash(strings.Join(workflowProjects(workspace, def.Name), ", ")))
+		}
+		fmt.Fprintf(&b, "\nUse `get_workflow` for a workflow's states, transitions, and gates.")
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// workflowKey returns the provenance key of a workflow file.
+func workflowKey(file string) string {
+	return packs.SyncKey(packs.ContentWorkflows, strings.TrimSuffix(file, filepath.Ext(file)))
+}
+
+// --- get_workflow ---
+
+func GetWorkflowSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "description": "Workflow file name under .claude/workflows/, with or without .yaml"},
+		},
+		"required": []any{"name"},
+	})
+	return s
+}
+
+func GetWorkflow(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+
+		file, err := packs.WorkflowFile(workspace, helpers.GetString(req.Arguments, "name"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return helpers.ErrorResult("not_found", err.Error()), nil
+		}
+		if err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+		def, err := workflow.LoadFromFile(filepath.Join(workspace, ".claude", "workflows", file))
+		if err != nil {
+			return helpers.ErrorResult("invalid_workflow", err.Error()), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Workflow: %s\n\n", cmp.Or(def.Name, file))
+		if def.Description != "" {
+			fmt.Fprintf(&b, "%s\n\n", strings.TrimSpace(def.Description))
+		}
+		fmt.Fprintf(&b, "- **File:** .claude/workflows/%s\n", file)
+		fmt.Fprintf(&b, "- **Initial state:** `%s`\n", def.InitialState)
+		if p := workspaceProvenance(ctx, ps, workspace)[workflowKey(file)]; p != nil {
+			fmt.Fprintf(&b, "- **Pack:** %s\n", p.String())
+		}
+		if projects := workflowProjects(workspace, def.Name); len(projects) > 0 {
+			fmt.Fprintf(&b, "- **Applied to:** %s\n", strings.Join(projects, ", "))
+		} else {
+			fmt.Fprintf(&b, "- **Applied to:** no project in this workspace\n")
+		}
+		fmt.Fprintf(&b, "\n%s", packs.DescribeWorkflow(def))
+		return helpers.TextResult(b.String()), nil
 	}
 }
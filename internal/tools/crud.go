@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
 	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
 	"github.com/orchestra-mcp/sdk-go/helpers"
 	"google.golang.org/protobuf/types/known/structpb"
@@ -131,7 +132,7 @@ func UpdateSkill(ps *storage.PackStorage) ToolHandler {
 			return helpers.ErrorResult("storage_error", err.Error()), nil
 		}
 
-		return helpers.TextResult(fmt.Sprintf("## Skill Updated\n\nSkill **%s** has been updated.", slug)), nil
+		return helpers.TextResult(fmt.Sprintf("## Skill Updated\n\nSkill **%s** has been updated.", slug) + packOwnedWarning("skill", meta)), nil
 	}
 }
 
@@ -285,7 +286,7 @@ func UpdateAgent(ps *storage.PackStorage) ToolHandler {
 			return helpers.ErrorResult("storage_error", err.Error()), nil
 		}
 
-		return helpers.TextResult(fmt.Sprintf("## Agent Updated\n\nAgent **%s** has been updated.", slug)), nil
+		return helpers.TextResult(fmt.Sprintf("## Agent Updated\n\nAgent **%s** has been updated.", slug) + packOwnedWarning("agent", meta)), nil
 	}
 }
 
@@ -450,7 +451,7 @@ func UpdateHook(ps *storage.PackStorage) ToolHandler {
 			return helpers.ErrorResult("storage_error", err.Error()), nil
 		}
 
-		return helpers.TextResult(fmt.Sprintf("## Hook Updated\n\nHook **%s** has been updated.", slug)), nil
+		return helpers.TextResult(fmt.Sprintf("## Hook Updated\n\nHook **%s** has been updated.", slug) + packOwnedWarning("hook", meta)), nil
 	}
 }
 
@@ -488,6 +489,16 @@ func DeleteHook(ps *storage.PackStorage) ToolHandler {
 // Helpers
 // ---------------------------------------------------------------------------
 
+// packOwnedWarning warns that an edit to content a pack installed lasts
+// only until the pack is updated, or returns "" for content of its own.
+func packOwnedWarning(kind string, meta map[string]any) string {
+	p := packs.ProvenanceFromMetadata(meta)
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("\n\n**Warning:** this %s was installed by %s. Once synced to .claude/ the edit is a local modification, and the next `update_pack %s` overwrites it. Create a copy under a new slug to keep your changes.", kind, p.String(), p.Pack)
+}
+
 // mergeMetadata takes existing metadata from storage and merges in new values
 // from the request arguments. Only non-empty argument values override existing ones.
 func mergeMetadata(existing *structpb.Struct, args *structpb.Struct, fields []string) map[string]any {
@@ -0,0 +1,627 @@
+package tools
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
+	"github.com/orchestra-mcp/sdk-go/helpers"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// gateHooks decides the review state of hooks a pack just staged. A hook
+// whose content was approved before is activated again and wired to its
+// event; one whose content was rejected is discarded; anything else stays
+// pending. It returns the new approval records, the names of the pending
+// hooks, and any settings.json wiring failures.
+func gateHooks(workspace, pack string, hooks []string, events map[string]packs.HookEvent, approvals map[string]*storage.HookApproval) (map[string]*storage.HookApproval, []string, []string) {
+	updated := make(map[string]*storage.HookApproval, len(hooks))
+	var pending, wiringErrs []string
+	for _, name := range hooks {
+		digest, _ := packs.FileDigest(packs.PendingHookPath(workspace, name))
+		prev := approvals[name]
+		switch {
+		case prev != nil && digest != "" && prev.ApprovedDigest == digest:
+			if err := packs.ActivateHook(workspace, name); err == nil {
+				updated[name] = &storage.HookApproval{Pack: pack, Status: storage.HookApproved, Digest: digest, ApprovedDigest: digest, ReviewedAt: prev.ReviewedAt}
+				if err := wireHook(workspace, name, events[name]); err != nil {
+					wiringErrs = append(wiringErrs, fmt.Sprintf("%s: %v", name, err))
+				}
+				continue
+			}
+		case prev != nil && digest != "" && prev.Status == storage.HookRejected && prev.Digest == digest:
+			packs.DiscardPendingHook(workspace, name)
+			updated[name] = prev
+			continue
+		}
+		a := &storage.HookApproval{Pack: pack, Status: storage.HookPending, Digest: digest}
+		if prev != nil {
+			a.ApprovedDigest = prev.ApprovedDigest
+		}
+		updated[name] = a
+		pending = append(pending, name)
+	}
+	return updated, pending, wiringErrs
+}
+
+// gateSyncedHooks decides the review state of hooks a content sync staged
+// from storage. Content that was approved before is activated again, keeping
+// its settings.json wiring; content that was rejected is discarded; anything
+// else waits for approve_hook, while the approved script stays installed.
+func gateSyncedHooks(workspace string, reg *storage.PackRegistry, prov map[string]*packs.Provenance, hooks []string) map[string]*storage.HookApproval {
+	updated := make(map[string]*storage.HookApproval, len(hooks))
+	for _, name := range hooks {
+		digest, err := packs.FileDigest(packs.PendingHookPath(workspace, name))
+		if err != nil {
+			continue
+		}
+		prev := reg.HookApprovals[name]
+		switch {
+		case prev != nil && prev.ApprovedDigest == digest:
+			if err := packs.ActivateHook(workspace, name); err == nil {
+				updated[name] = &storage.HookApproval{Pack: prev.Pack, Status: storage.HookApproved, Digest: digest, ApprovedDigest: digest, ReviewedAt: prev.ReviewedAt}
+				continue
+			}
+		case prev != nil && prev.Status == storage.HookRejected && prev.Digest == digest:
+			packs.DiscardPendingHook(workspace, name)
+			continue
+		}
+		a := &storage.HookApproval{Status: storage.HookPending, Digest: digest}
+		if prev != nil {
+			a.Pack, a.ApprovedDigest = prev.Pack, prev.ApprovedDigest
+		} else if p := prov[packs.SyncKey(packs.ContentHooks, name)]; p != nil {
+			a.Pack = p.Pack
+		}
+		updated[name] = a
+	}
+	return updated
+}
+
+// wireHook registers an active hook in settings.json for the event its pack
+// declares, or removes the marketplace's entries if it declares none.
+func wireHook(workspace, name string, ev packs.HookEvent) error {
+	if ev.Event == "" {
+		return packs.UnwireHook(workspace, name)
+	}
+	return packs.WireHook(workspace, name, ev)
+}
+
+// registryHookEvents converts manifest hook wiring for the registry.
+func registryHookEvents(events map[string]packs.HookEvent) map[string]storage.HookEvent {
+	if len(events) == 0 {
+		return nil
+	}
+	out := make(map[string]storage.HookEvent, len(events))
+	for name, ev := range events {
+		out[name] = storage.HookEvent(ev)
+	}
+	return out
+}
+
+// describeHookEvent renders wiring as "Event (matcher, 30s)".
+func describeHookEvent(event, matcher string, timeout int) string {
+	var details []string
+	if matcher != "" {
+		details = append(details, "`"+matcher+"`")
+	}
+	if timeout > 0 {
+		details = append(details, fmt.Sprintf("%ds", timeout))
+	}
+	if len(details) == 0 {
+		return event
+	}
+	return event + " (" + strings.Join(details, ", ") + ")"
+}
+
+// recordHookApprovals merges approval records into the registry.
+func recordHookApprovals(reg *storage.PackRegistry, approvals map[string]*storage.HookApproval) {
+	if len(approvals) == 0 {
+		return
+	}
+	if reg.HookApprovals == nil {
+		reg.HookApprovals = make(map[string]*storage.HookApproval)
+	}
+	for name, a := range approvals {
+		reg.HookApprovals[name] = a
+	}
+}
+
+// --- review_hooks ---
+
+func ReviewHooksSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":   map[string]any{"type": "string", "description": "Hook name to review (omit to review all hooks with the given status)"},
+			"status": map[string]any{"type": "string", "enum": []any{"pending", "approved", "rejected", "all"}, "description": "Which hooks to show (default: pending)"},
+		},
+	})
+	return s
+}
+
+func ReviewHooks(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		name := helpers.GetString(req.Arguments, "name")
+		status := helpers.GetString(req.Arguments, "status")
+		if status == "" {
+			status = storage.HookPending
+		}
+
+		reg, _, err := ps.ReadRegistry(ctx)
+		if err != nil {
+			return registryError(err), nil
+		}
+
+		var names []string
+		if name != "" {
+			if _, ok := reg.HookApprovals[name]; !ok {
+				return helpers.ErrorResult("not_found", fmt.Sprintf("hook %q was not staged for review by a pack or a content sync", name)), nil
+			}
+			names = []string{name}
+		} else {
+			for hookName, a := range reg.HookApprovals {
+				if status == "all" || a.Status == status {
+					names = append(names, hookName)
+				}
+			}
+			sort.Strings(names)
+		}
+		if len(names) == 0 {
+			return helpers.TextResult(fmt.Sprintf("## Hook Review\n\nNo %s hooks.", status)), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Hook Review (%d)\n", len(names))
+		for _, hookName := range names {
+			writeHookReview(&b, workspace, hookName, reg.HookApprovals[hookName])
+		}
+		fmt.Fprintf(&b, "\nApprove a hook with `approve_hook` (pass its digest to approve exactly this content) or reject it with `reject_hook`.")
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// writeHookReview renders one hook: its state, the diff against the approved
+// version still installed, and the full source under review.
+func writeHookReview(b *strings.Builder, workspace, name string, a *storage.HookApproval) {
+	fmt.Fprintf(b, "\n### %s (%s) — %s\n\n", name, orDash(a.Pack), a.Status)
+	fmt.Fprintf(b, "- **Digest:** `%s`\n", orDash(a.Digest))
+	if a.ApprovedDigest != "" && a.ApprovedDigest != a.Digest {
+		fmt.Fprintf(b, "- **Approved version:** `%s` (still installed)\n", a.ApprovedDigest)
+	}
+	if a.ReviewedAt != "" {
+		fmt.Fprintf(b, "- **Reviewed:** %s\n", a.ReviewedAt)
+	}
+	if a.Reason != "" {
+		fmt.Fprintf(b, "- **Reason:** %s\n", a.Reason)
+	}
+
+	path := packs.HookPath(workspace, name)
+	if a.Status == storage.HookPending {
+		path = packs.PendingHookPath(workspace, name)
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		if a.Status != storage.HookRejected {
+			fmt.Fprintf(b, "\nSource unavailable: %v\n", err)
+		}
+		return
+	}
+	scan := packs.ScanHook(source)
+	fmt.Fprintf(b, "- **Risk:** %s\n", scan.Summary())
+	writeFindings(b, scan)
+
+	if a.Status == storage.HookPending {
+		if approved, err := os.ReadFile(packs.HookPath(workspace, name)); err == nil {
+			if diff := packs.DiffLines("approved/"+name+".sh", "pending/"+name+".sh", string(approved), string(source)); diff != "" {
+				fmt.Fprintf(b, "\n#### Changes since approved version\n\n```diff\n%s```\n", diff)
+			} else {
+				fmt.Fprintf(b, "\nContent is identical to the installed version.\n")
+			}
+		}
+	}
+	fmt.Fprintf(b, "\n#### Source\n\n```bash\n%s\n```\n", strings.TrimRight(string(source), "\n"))
+}
+
+// writeFindings lists the findings of a hook scan, one per line.
+func writeFindings(b *strings.Builder, scan *packs.HookScan) {
+	for _, f := range scan.Findings {
+		fmt.Fprintf(b, "  - line %d, **%s** `%s`: %s\n", f.Line, f.Level, f.Rule, f.Message)
+	}
+}
+
+// --- approve_hook ---
+
+func ApproveHookSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":         map[string]any{"type": "string", "description": "Pending hook to approve"},
+			"digest":       map[string]any{"type": "string", "description": "Digest shown by review_hooks; approval fails if the content no longer matches it"},
+			"wait_seconds": map[string]any{"type": "number", "description": "Seconds to wait if another pack operation holds the workspace lock (default 30, 0 to fail immediately)"},
+		},
+		"required": []any{"name"},
+	})
+	return s
+}
+
+func ApproveHook(ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+		name := helpers.GetString(req.Arguments, "name")
+		expected := helpers.GetString(req.Arguments, "digest")
+
+		release, busy := acquireWorkspace(ctx, lock, "approve_hook "+name, req.Arguments)
+		if busy != nil {
+			return busy, nil
+		}
+		defer release()
+
+		a, resp := pendingHook(ctx, ps, name)
+		if resp != nil {
+			return resp, nil
+		}
+		digest, err := packs.FileDigest(packs.PendingHookPath(workspace, name))
+		if err != nil {
+			return helpers.ErrorResult("not_found", fmt.Sprintf("staged copy of hook %q is missing; reinstall its pack", name)), nil
+		}
+		if digest != a.Digest {
+			return helpers.ErrorResult("hook_changed", fmt.Sprintf("hook %q changed since it was staged; run review_hooks again", name)), nil
+		}
+		if expected != "" && expected != digest {
+			return helpers.ErrorResult("hook_changed", fmt.Sprintf("hook %q is %s, not the reviewed %s", name, digest, expected)), nil
+		}
+
+		if err := packs.ActivateHook(workspace, name); err != nil {
+			return helpers.ErrorResult("approve_error", err.Error()), nil
+		}
+		var event storage.HookEvent
+		if _, err := ps.UpdateRegistry(ctx, func(reg *storage.PackRegistry) error {
+			recordHookApprovals(reg, map[string]*storage.HookApproval{name: {
+				Pack: a.Pack, Status: storage.HookApproved, Digest: digest, ApprovedDigest: digest, ReviewedAt: helpers.NowISO(),
+			}})
+			if entry := reg.Packs[a.Pack]; entry != nil {
+				event = entry.HookEvents[name]
+			}
+			return nil
+		}); err != nil {
+			return registryError(err), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Approved: %s\n\n- **Pack:** %s\n- **Digest:** `%s`\n", name, orDash(a.Pack), digest)
+		if a.Pack == "" {
+			fmt.Fprintf(&b, "- **Wired:** unchanged (the hook came from storage, not a pack)\n")
+		} else {
+			switch err := wireHook(workspace, name, packs.HookEvent(event)); {
+			case err != nil:
+				fmt.Fprintf(&b, "- **Wiring failed:** %v\n", err)
+			case event.Event != "":
+				fmt.Fprintf(&b, "- **Wired:** %s in .claude/settings.json\n", describeHookEvent(event.Event, event.Matcher, event.Timeout))
+			default:
+				fmt.Fprintf(&b, "- **Wired:** no (the pack declares no event; add it to .claude/settings.json by hand)\n")
+			}
+		}
+		b.WriteString("\nThe hook is installed and executable. It returns to pending if a pack update or a storage edit changes it.")
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// --- reject_hook ---
+
+func RejectHookSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":         map[string]any{"type": "string", "description": "Pending hook to reject"},
+			"reason":       map[string]any{"type": "string", "description": "Why the hook was rejected (optional, shown by review_hooks)"},
+			"wait_seconds": map[string]any{"type": "number", "description": "Seconds to wait if another pack operation holds the workspace lock (default 30, 0 to fail immediately)"},
+		},
+		"required": []any{"name"},
+	})
+	return s
+}
+
+func RejectHook(ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+		name := helpers.GetString(req.Arguments, "name")
+
+		release, busy := acquireWorkspace(ctx, lock, "reject_hook "+name, req.Arguments)
+		if busy != nil {
+			return busy, nil
+		}
+		defer release()
+
+		a, resp := pendingHook(ctx, ps, name)
+		if resp != nil {
+			return resp, nil
+		}
+		if err := packs.DiscardPendingHook(workspace, name); err != nil {
+			return helpers.ErrorResult("reject_error", err.Error()), nil
+		}
+		if _, err := ps.UpdateRegistry(ctx, func(reg *storage.PackRegistry) error {
+			recordHookApprovals(reg, map[string]*storage.HookApproval{name: {
+				Pack: a.Pack, Status: storage.HookRejected, Digest: a.Digest, ApprovedDigest: a.ApprovedDigest,
+				ReviewedAt: helpers.NowISO(), Reason: helpers.GetString(req.Arguments, "reason"),
+			}})
+			return nil
+		}); err != nil {
+			return registryError(err), nil
+		}
+
+		msg := fmt.Sprintf("## Rejected: %s\n\n- **Pack:** %s\n\n", name, orDash(a.Pack))
+		if a.ApprovedDigest != "" {
+			msg += "The previously approved version stays installed."
+		} else {
+			msg += "The hook is not installed. The same content will not be offered for review again."
+		}
+		return helpers.TextResult(msg), nil
+	}
+}
+
+// pendingHook loads the approval record of a hook awaiting review.
+func pendingHook(ctx context.Context, ps *storage.PackStorage, name string) (*storage.HookApproval, *pluginv1.ToolResponse) {
+	reg, _, err := ps.ReadRegistry(ctx)
+	if err != nil {
+		return nil, registryError(err)
+	}
+	a, ok := reg.HookApprovals[name]
+	if !ok {
+		return nil, helpers.ErrorResult("not_found", fmt.Sprintf("hook %q was not staged for review by a pack or a content sync", name))
+	}
+	if a.Status != storage.HookPending {
+		return nil, helpers.ErrorResult("validation_error", fmt.Sprintf("hook %q is %s, not pending review", name, a.Status))
+	}
+	return a, nil
+}
+
+// --- audit_hooks ---
+
+func AuditHooksSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":      map[string]any{"type": "string", "description": "Hook name to audit (omit to audit all)"},
+			"min_level": map[string]any{"type": "string", "enum": []any{"none", "low", "medium", "high", "critical"}, "description": "Only show hooks at or above this risk level (default: none)"},
+		},
+	})
+	return s
+}
+
+func AuditHooks(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		name := helpers.GetString(req.Arguments, "name")
+		minLevel := packs.RiskNone
+		if v := helpers.GetString(req.Arguments, "min_level"); v != "" {
+			level, err := packs.ParseRiskLevel(v)
+			if err != nil {
+				return helpers.ErrorResult("validation_error", err.Error()), nil
+			}
+			minLevel = level
+		}
+		policies, err := packs.LoadPolicies(workspace)
+		if err != nil {
+			return helpers.ErrorResult("invalid_policy", err.Error()), nil
+		}
+		limit, limited := policies.MaxHookRisk()
+
+		type audited struct {
+			name, source string
+			scan         *packs.HookScan
+		}
+		var hooks []audited
+		add := func(hookName, source string, content []byte) {
+			if name == "" || hookName == name {
+				hooks = append(hooks, audited{hookName, source, packs.ScanHook(content)})
+			}
+		}
+		for _, hookName := range packs.ListInstalledHooks(workspace) {
+			if data, err := os.ReadFile(packs.HookPath(workspace, hookName)); err == nil {
+				add(hookName, "installed", data)
+			}
+		}
+		for _, hookName := range packs.ListPendingHooks(workspace) {
+			if data, err := os.ReadFile(packs.PendingHookPath(workspace, hookName)); err == nil {
+				add(hookName, "pending review", data)
+			}
+		}
+		entries, _ := ps.StorageList(ctx, ".hooks", "*.md")
+		for _, e := range entries {
+			if resp, err := ps.StorageRead(ctx, e.Path); err == nil {
+				add(strings.TrimSuffix(path.Base(e.Path), ".md"), "storage", resp.Content)
+			}
+		}
+
+		if name != "" && len(hooks) == 0 {
+			return helpers.ErrorResult("not_found", fmt.Sprintf("hook %q not found", name)), nil
+		}
+		hooks = slices.DeleteFunc(hooks, func(h audited) bool { return h.scan.Level < minLevel })
+		slices.SortStableFunc(hooks, func(a, b audited) int {
+			return cmp.Or(cmp.Compare(b.scan.Score, a.scan.Score), cmp.Compare(a.name, b.name))
+		})
+		if len(hooks) == 0 {
+			return helpers.TextResult("## Hook Audit\n\nNo hooks to audit."), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Hook Audit (%d)\n\n", len(hooks))
+		if limited {
+			fmt.Fprintf(&b, "Policy allows hooks up to **%s** risk.\n\n", limit)
+		}
+		fmt.Fprintf(&b, "| Hook | Source | Risk | Score | Findings |\n")
+		fmt.Fprintf(&b, "|------|--------|------|-------|----------|\n")
+		for _, h := range hooks {
+			risk := h.scan.Level.String()
+			if h.scan.Level > limit {
+				risk += " (over policy)"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %d |\n", h.name, h.source, risk, h.scan.Score, len(h.scan.Findings))
+		}
+		for _, h := range hooks {
+			if len(h.scan.Findings) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "\n### %s (%s)\n\n", h.name, h.source)
+			writeFindings(&b, h.scan)
+		}
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// --- test_hook ---
+
+func TestHookSchema() *structpb.Struct {
+	events := make([]any, len(packs.KnownHookEvents))
+	for i, e := range packs.KnownHookEvents {
+		events[i] = e
+	}
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":            map[string]any{"type": "string", "description": "Hook to run"},
+			"source":          map[string]any{"type": "string", "enum": []any{"installed", "pending", "storage"}, "description": "Which copy to run (default: installed, then pending review, then storage)"},
+			"event":           map[string]any{"type": "string", "enum": events, "description": "Event to simulate (default: the event the hook is wired to, else PreToolUse)"},
+			"tool_name":       map[string]any{"type": "string", "description": "Tool for PreToolUse/PostToolUse payloads (default: one the hook's matcher accepts, else Bash)"},
+			"payload":         map[string]any{"type": "object", "description": "Fields merged over the synthetic event payload, e.g. {\"tool_input\": {\"command\": \"rm -rf /\"}}"},
+			"timeout_seconds": map[string]any{"type": "number", "description": "Seconds before the hook is killed (default 10, max 60)"},
+		},
+		"required": []any{"name"},
+	})
+	return s
+}
+
+func TestHook(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
+			return helpers.ErrorResult("validation_error", err.Error()), nil
+		}
+		name := helpers.GetString(req.Arguments, "name")
+		source := helpers.GetString(req.Arguments, "source")
+		if source != "" && !slices.Contains([]string{"installed", "pending", "storage"}, source) {
+			return helpers.ErrorResult("validation_error", fmt.Sprintf("unknown source %q (want installed, pending, or storage)", source)), nil
+		}
+		var overrides map[string]any
+		if v, ok := req.Arguments.Fields["payload"]; ok {
+			obj := v.GetStructValue()
+			if obj == nil {
+				return helpers.ErrorResult("validation_error", "payload must be an object"), nil
+			}
+			overrides = obj.AsMap()
+		}
+
+		reg, _, err := ps.ReadRegistry(ctx)
+		if err != nil {
+			return registryError(err), nil
+		}
+		script, from, declared, resp := loadHookForTest(ctx, ps, workspace, reg, name, source)
+		if resp != nil {
+			return resp, nil
+		}
+
+		t := packs.HookTest{
+			Name:     name,
+			Event:    helpers.GetString(req.Arguments, "event"),
+			Matcher:  declared.Matcher,
+			ToolName: helpers.GetString(req.Arguments, "tool_name"),
+			Payload:  overrides,
+			Timeout:  time.Duration(helpers.GetFloat64(req.Arguments, "timeout_seconds") * float64(time.Second)),
+		}
+		eventNote := "requested"
+		switch {
+		case t.Event != "":
+		case declared.Event != "":
+			t.Event, eventNote = declared.Event, "declared"
+		default:
+			t.Event, eventNote = "PreToolUse", "assumed; the hook declares no event"
+		}
+
+		run, err := packs.RunHook(ctx, script, t)
+		if err != nil {
+			return helpers.ErrorResult("test_error", err.Error()), nil
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Hook Test: %s\n\n", name)
+		fmt.Fprintf(&b, "- **Source:** %s\n", from)
+		fmt.Fprintf(&b, "- **Event:** %s (%s)\n", t.Event, eventNote)
+		if run.TimedOut {
+			fmt.Fprintf(&b, "- **Exit code:** none — %s\n", run.Outcome())
+		} else {
+			fmt.Fprintf(&b, "- **Exit code:** %d — %s\n", run.ExitCode, run.Outcome())
+		}
+		fmt.Fprintf(&b, "- **Runtime:** %s\n", run.Duration.Round(time.Millisecond))
+		fmt.Fprintf(&b, "- **Sandbox:** %s\n", strings.Join(run.Sandbox, ", "))
+		if run.Truncated {
+			fmt.Fprintf(&b, "- **Output truncated** to the first 64 KiB of each stream\n")
+		}
+		writeOutputBlock(&b, "stdout", run.Stdout)
+		writeOutputBlock(&b, "stderr", run.Stderr)
+		fmt.Fprintf(&b, "\n### Payload\n\n```json\n%s\n```\n", run.Payload)
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// loadHookForTest reads the script of a hook from the requested source, or
+// from the first source that has it, along with the event it is wired to.
+func loadHookForTest(ctx context.Context, ps *storage.PackStorage, workspace string, reg *storage.PackRegistry, name, source string) ([]byte, string, packs.HookEvent, *pluginv1.ToolResponse) {
+	var declared packs.HookEvent
+	if a := reg.HookApprovals[name]; a != nil && reg.Packs[a.Pack] != nil {
+		declared = packs.HookEvent(reg.Packs[a.Pack].HookEvents[name])
+	}
+	if declared.Event == "" {
+		if wiring, _ := packs.ReadHookWiring(workspace); len(wiring[name]) > 0 {
+			w := wiring[name][0]
+			declared = packs.HookEvent{Event: w.Event, Matcher: w.Matcher, Timeout: w.Timeout}
+		}
+	}
+
+	if source == "" || source == "installed" {
+		if data, err := os.ReadFile(packs.HookPath(workspace, name)); err == nil {
+			return data, "installed (.claude/hooks/" + name + ".sh)", declared, nil
+		}
+	}
+	if source == "" || source == "pending" {
+		if data, err := os.ReadFile(packs.PendingHookPath(workspace, name)); err == nil {
+			return data, "pending review", declared, nil
+		}
+	}
+	if source == "" || source == "storage" {
+		if resp, err := ps.StorageRead(ctx, ".hooks/"+name+".md"); err == nil {
+			var stored packs.HookEvent
+			if eventType, _ := resp.Metadata.AsMap()["event_type"].(string); eventType != "" {
+				for _, known := range packs.KnownHookEvents {
+					if strings.EqualFold(eventType, known) {
+						stored.Event = known
+					}
+				}
+			}
+			return resp.Content, "storage (.hooks/" + name + ".md)", stored, nil
+		}
+	}
+	if source != "" {
+		return nil, "", declared, helpers.ErrorResult("not_found", fmt.Sprintf("hook %q has no %s copy", name, source))
+	}
+	return nil, "", declared, helpers.ErrorResult("not_found", fmt.Sprintf("hook %q not found", name))
+}
+
+// writeOutputBlock renders captured hook output in a code fence.
+func writeOutputBlock(b *strings.Builder, stream, out string) {
+	if out == "" {
+		fmt.Fprintf(b, "\n### %s\n\n(empty)\n", stream)
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n```\n%s\n```\n", stream, strings.TrimRight(out, "\n"))
+}
@@ -0,0 +1,152 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
+	"github.com/orchestra-mcp/sdk-go/helpers"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// --- license_report ---
+
+func LicenseReportSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"format":  map[string]any{"type": "string", "enum": []any{"markdown", "json", "csv"}, "description": "Report format (default: markdown)"},
+			"allowed": map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "SPDX licenses to allow (optional, defaults to allowed_licenses from the install policies)"},
+			"output":  map[string]any{"type": "string", "description": "Also write the report to this file, relative to the workspace (optional)"},
+		},
+	})
+	return s
+}
+
+func LicenseReport(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		format := helpers.GetString(req.Arguments, "format")
+		if format == "" {
+			format = "markdown"
+		}
+		if !slices.Contains([]string{"markdown", "json", "csv"}, format) {
+			return helpers.ErrorResult("validation_error", fmt.Sprintf("unknown format %q (want markdown, json, or csv)", format)), nil
+		}
+
+		var allowlists [][]string
+		source := "policy"
+		if allowed := helpers.GetStringSlice(req.Arguments, "allowed"); len(allowed) > 0 {
+			allowlists, source = [][]string{allowed}, "request"
+		} else {
+			policies, err := packs.LoadPolicies(workspace)
+			if err != nil {
+				return helpers.ErrorResult("invalid_policy", err.Error()), nil
+			}
+			for _, p := range policies {
+				if len(p.AllowedLicenses) > 0 {
+					allowlists = append(allowlists, p.AllowedLicenses)
+				}
+			}
+		}
+
+		reg, _, err := ps.ReadRegistry(ctx)
+		if err != nil {
+			return registryError(err), nil
+		}
+		rows := make([]packs.LicenseRow, 0, len(reg.Packs))
+		for _, name := range slices.Sorted(maps.Keys(reg.Packs)) {
+			entry := reg.Packs[name]
+			status, note := packs.CheckLicense(entry.License, allowlists)
+			rows = append(rows, packs.LicenseRow{
+				Pack:    name,
+				Version: entry.Version,
+				Repo:    entry.Repo,
+				License: entry.License,
+				Status:  status,
+				Note:    note,
+			})
+		}
+
+		var out bytes.Buffer
+		switch format {
+		case "json":
+			data, err := json.MarshalIndent(rows, "", "  ")
+			if err != nil {
+				return helpers.ErrorResult("report_error", err.Error()), nil
+			}
+			out.Write(data)
+			out.WriteByte('\n')
+		case "csv":
+			if err := packs.WriteLicenseCSV(&out, rows); err != nil {
+				return helpers.ErrorResult("report_error", err.Error()), nil
+			}
+		default:
+			writeLicenseReport(&out, rows, allowlists, source)
+		}
+
+		text := out.String()
+		if output := helpers.GetString(req.Arguments, "output"); output != "" {
+			file, err := packs.WorkspacePath(workspace, output)
+			if err != nil {
+				return helpers.ErrorResult("validation_error", "output: "+err.Error()), nil
+			}
+			if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+				return helpers.ErrorResult("report_error", err.Error()), nil
+			}
+			if err := os.WriteFile(file, out.Bytes(), 0644); err != nil {
+				return helpers.ErrorResult("report_error", err.Error()), nil
+			}
+			if format != "markdown" {
+				text = fmt.Sprintf("Wrote %s license report for %d pack(s) to %s.\n", strings.ToUpper(format), len(rows), file)
+			} else {
+				text += fmt.Sprintf("\nWritten to %s.\n", file)
+			}
+		}
+		return helpers.TextResult(text), nil
+	}
+}
+
+// writeLicenseReport renders license report rows as markdown.
+func writeLicenseReport(b *bytes.Buffer, rows []packs.LicenseRow, allowlists [][]string, source string) {
+	if len(rows) == 0 {
+		b.WriteString("## License Report\n\nNo packs installed.\n")
+		return
+	}
+	fmt.Fprintf(b, "## License Report (%d)\n\n", len(rows))
+	if len(allowlists) == 0 {
+		b.WriteString("No license allowlist is configured; only missing and invalid licenses are flagged.\n\n")
+	} else {
+		for _, allowed := range allowlists {
+			fmt.Fprintf(b, "Allowed (%s): %s\n", source, strings.Join(allowed, ", "))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(b, "| Pack | Version | License | Status | Note |\n")
+	fmt.Fprintf(b, "|------|---------|---------|--------|------|\n")
+	counts := make(map[string]int)
+	for _, r := range rows {
+		status := r.Status
+		if status != packs.LicenseOK && status != packs.LicenseUnchecked {
+			status = "**" + status + "**"
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s |\n", r.Pack, r.Version, orDash(r.License), status, orDash(r.Note))
+		counts[r.Status]++
+	}
+	b.WriteString("\n")
+	var summary []string
+	for _, status := range []string{packs.LicenseOK, packs.LicenseUnchecked, packs.LicenseDisallowed, packs.LicenseMissing, packs.LicenseInvalid} {
+		if counts[status] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[status], status))
+		}
+	}
+	fmt.Fprintf(b, "**Summary:** %s\n", strings.Join(summary, ", "))
+}
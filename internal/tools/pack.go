@@ -1,12 +1,18 @@
 package tools
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"maps"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
 	"github.com/orchestra-mcp/sdk-go/globaldb"
@@ -26,16 +32,17 @@ func InstallPackSchema() *structpb.Struct {
 	s, _ := structpb.NewStruct(map[string]any{
 		"type": "object",
 		"properties": map[string]any{
-			"repo":       map[string]any{"type": "string", "description": "Pack name or GitHub repo. Short names (e.g., 'go-backend'), org/repo (e.g., 'orchestra-mcp/pack-go-backend'), or full path (e.g., 'github.com/orchestra-mcp/pack-go-backend') are all supported."},
-			"version":    map[string]any{"type": "string", "description": "Version tag (optional, defaults to latest)"},
-			"project_id": map[string]any{"type": "string", "description": "Project slug to apply workflow to (optional, auto-detected if omitted)"},
+			"repo":         map[string]any{"type": "string", "description": "Pack name or GitHub repo. Short names (e.g., 'go-backend'), org/repo (e.g., 'orchestra-mcp/pack-go-backend'), or full path (e.g., 'github.com/orchestra-mcp/pack-go-backend') are all supported."},
+			"version":      map[string]any{"type": "string", "description": "Version constraint (e.g., '^1.2', '~0.3.1', '1.4.0'), tag, or branch (optional, defaults to latest). Recorded so update_pack stays within it."},
+			"project_id":   map[string]any{"type": "string", "description": "Project slug to apply workflow to (optional, auto-detected if omitted)"},
+			"wait_seconds": map[string]any{"type": "number", "description": "Seconds to wait if another pack operation holds the workspace lock (default 30, 0 to fail immediately)"},
 		},
 		"required": []any{"repo"},
 	})
 	return s
 }
 
-func InstallPack(ps *storage.PackStorage, workspace string) ToolHandler {
+func InstallPack(ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock) ToolHandler {
 	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
 		if err := helpers.ValidateRequired(req.Arguments, "repo"); err != nil {
 			return helpers.ErrorResult("validation_error", err.Error()), nil
@@ -48,35 +55,91 @@ func InstallPack(ps *storage.PackStorage, workspace string) ToolHandler {
 		// Resolve short names (e.g., "go-backend") and org/repo to full paths.
 		repo := packs.ResolvePackRepo(repoInput)
 
-		manifest, err := packs.InstallPack(workspace, repo, version)
+		release, busy := acquireWorkspace(ctx, lock, "install_pack "+repo, req.Arguments)
+		if busy != nil {
+			return busy, nil
+		}
+		defer release()
+
+		policies, err := packs.LoadPolicies(workspace)
 		if err != nil {
-			return helpers.ErrorResult("install_error", err.Error()), nil
+			return helpers.ErrorResult("invalid_policy", err.Error()), nil
+		}
+		if err := policies.CheckSource(repo); err != nil {
+			return policyError(err), nil
 		}
 
-		// Update registry.
-		reg, regVersion, err := ps.ReadRegistry(ctx)
+		reg, _, err := ps.ReadRegistry(ctx)
 		if err != nil {
-			return helpers.ErrorResult("storage_error", err.Error()), nil
+			return registryError(err), nil
+		}
+		ref, err := packs.ResolveRef(ctx, repo, version)
+		if err != nil {
+			return helpers.ErrorResult("install_error", err.Error()), nil
+		}
+		fetched, err := packs.FetchPack(ctx, repo, ref)
+		if err != nil {
+			var invalid *packs.ManifestError
+			if errors.As(err, &invalid) {
+				return helpers.ErrorResult("invalid_manifest", err.Error()), nil
+			}
+			var unsafe *packs.UnsafePackError
+			if errors.As(err, &unsafe) {
+				return helpers.ErrorResult("unsafe_pack", err.Error()+". Nothing was installed."), nil
+			}
+			return helpers.ErrorResult("install_error", err.Error()), nil
+		}
+		defer fetched.Cleanup()
+		verification, digests, err := verifyFetched(fetched, reg)
+		if err != nil {
+			var integrity *packs.IntegrityError
+			if errors.As(err, &integrity) {
+				return helpers.ErrorResult("integrity_error", err.Error()+". Nothing was installed."), nil
+			}
+			return helpers.ErrorResult("install_error", err.Error()), nil
+		}
+		scans, err := enforcePolicies(policies, fetched, verification.Signer)
+		if err != nil {
+			return policyError(err), nil
 		}
+		if err := fetched.Apply(workspace); err != nil {
+			return helpers.ErrorResult("install_error", err.Error()), nil
+		}
+		manifest := fetched.Manifest
+		approvals, pendingHooks, wiringErrs := gateHooks(workspace, manifest.Name, manifest.Contents.Hooks, manifest.HookEvents, reg.HookApprovals)
 
-		reg.Packs[manifest.Name] = &storage.PackEntry{
+		// Update registry.
+		entry := &storage.PackEntry{
 			Version:     manifest.Version,
 			Repo:        repo,
+			Commit:      fetched.Commit,
+			Constraint:  version,
 			InstalledAt: helpers.NowISO(),
 			Stacks:      manifest.Stacks,
+			Requires:    manifest.Requires,
 			Skills:      manifest.Contents.Skills,
 			Agents:      manifest.Contents.Agents,
 			Hooks:       manifest.Contents.Hooks,
 			Workflows:   manifest.Contents.Workflows,
+			License:     manifest.License,
+			Digests:     digests,
+			Signer:      verification.Signer,
+			HookEvents:  registryHookEvents(manifest.HookEvents),
 		}
-
-		if _, err := ps.WriteRegistry(ctx, reg, regVersion); err != nil {
-			return helpers.ErrorResult("storage_error", err.Error()), nil
+		reg, err = ps.UpdateRegistry(ctx, func(reg *storage.PackRegistry) error {
+			reg.Packs[manifest.Name] = entry
+			recordHookApprovals(reg, approvals)
+			return nil
+		})
+		if err != nil {
+			return registryError(err), nil
 		}
 
 		var b strings.Builder
 		fmt.Fprintf(&b, "## Installed: %s\n\n", manifest.Name)
 		fmt.Fprintf(&b, "- **Version:** %s\n", manifest.Version)
+		fmt.Fprintf(&b, "- **License:** %s\n", orDash(manifest.License))
+		fmt.Fprintf(&b, "- **Integrity:** %s\n", verification.Summary())
 		if len(manifest.Contents.Skills) > 0 {
 			fmt.Fprintf(&b, "- **Skills:** %s\n", strings.Join(manifest.Contents.Skills, ", "))
 		}
@@ -86,6 +149,23 @@ func InstallPack(ps *storage.PackStorage, workspace string) ToolHandler {
 		if len(manifest.Contents.Hooks) > 0 {
 			fmt.Fprintf(&b, "- **Hooks:** %s\n", strings.Join(manifest.Contents.Hooks, ", "))
 		}
+		for _, hook := range manifest.Contents.Hooks {
+			fmt.Fprintf(&b, "- **Hook risk:** %s: %s\n", hook, scans[hook].Summary())
+		}
+		for _, hook := range manifest.Contents.Hooks {
+			if ev, ok := manifest.HookEvents[hook]; ok {
+				fmt.Fprintf(&b, "- **Hook event:** %s: %s\n", hook, describeHookEvent(ev.Event, ev.Matcher, ev.Timeout))
+			}
+		}
+		if len(pendingHooks) > 0 {
+			fmt.Fprintf(&b, "- **Pending review:** %s (not active or wired until approved; see `review_hooks`)\n", strings.Join(pendingHooks, ", "))
+		}
+		for _, problem := range wiringErrs {
+			fmt.Fprintf(&b, "- **Hook wiring failed:** %s\n", problem)
+		}
+		if err := recordProvenance(workspace, reg); err != nil {
+			fmt.Fprintf(&b, "- **Provenance warning:** %v\n", err)
+		}
 
 		// Apply workflows to the active project.
 		if len(manifest.Contents.Workflows) > 0 {
@@ -117,14 +197,15 @@ func RemovePackSchema() *structpb.Struct {
 	s, _ := structpb.NewStruct(map[string]any{
 		"type": "object",
 		"properties": map[string]any{
-			"name": map[string]any{"type": "string", "description": "Pack name (e.g., orchestra-mcp/pack-go-backend)"},
+			"name":         map[string]any{"type": "string", "description": "Pack name (e.g., orchestra-mcp/pack-go-backend)"},
+			"wait_seconds": map[string]any{"type": "number", "description": "Seconds to wait if another pack operation holds the workspace lock (default 30, 0 to fail immediately)"},
 		},
 		"required": []any{"name"},
 	})
 	return s
 }
 
-func RemovePack(ps *storage.PackStorage, workspace string) ToolHandler {
+func RemovePack(ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock) ToolHandler {
 	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
 		if err := helpers.ValidateRequired(req.Arguments, "name"); err != nil {
 			return helpers.ErrorResult("validation_error", err.Error()), nil
@@ -132,23 +213,39 @@ func RemovePack(ps *storage.PackStorage, workspace string) ToolHandler {
 
 		name := helpers.GetString(req.Arguments, "name")
 
-		reg, regVersion, err := ps.ReadRegistry(ctx)
-		if err != nil {
-			return helpers.ErrorResult("storage_error", err.Error()), nil
+		release, busy := acquireWorkspace(ctx, lock, "remove_pack "+name, req.Arguments)
+		if busy != nil {
+			return busy, nil
 		}
+		defer release()
 
-		entry, ok := reg.Packs[name]
-		if !ok {
+		// Drop the registry entry first so a concurrent update can't re-add
+		// files for a pack that is being removed.
+		var entry *storage.PackEntry
+		reg, err := ps.UpdateRegistry(ctx, func(reg *storage.PackRegistry) error {
+			e, ok := reg.Packs[name]
+			if !ok {
+				return errPackNotInstalled
+			}
+			entry = e
+			delete(reg.Packs, name)
+			for _, hook := range e.Hooks {
+				delete(reg.HookApprovals, hook)
+			}
+			return nil
+		})
+		if errors.Is(err, errPackNotInstalled) {
 			return helpers.ErrorResult("not_found", fmt.Sprintf("pack %q not installed", name)), nil
 		}
+		if err != nil {
+			return registryError(err), nil
+		}
 
 		if err := packs.RemovePack(workspace, entry.Skills, entry.Agents, entry.Hooks, entry.Workflows); err != nil {
 			return helpers.ErrorResult("remove_error", err.Error()), nil
 		}
-
-		delete(reg.Packs, name)
-		if _, err := ps.WriteRegistry(ctx, reg, regVersion); err != nil {
-			return helpers.ErrorResult("storage_error", err.Error()), nil
+		if err := recordProvenance(workspace, reg); err != nil {
+			return helpers.TextResult(fmt.Sprintf("Removed pack: %s\n\nProvenance warning: %v", name, err)), nil
 		}
 
 		return helpers.TextResult(fmt.Sprintf("Removed pack: %s", name)), nil
@@ -161,48 +258,139 @@ func UpdatePackSchema() *structpb.Struct {
 	s, _ := structpb.NewStruct(map[string]any{
 		"type": "object",
 		"properties": map[string]any{
-			"name":       map[string]any{"type": "string", "description": "Pack name to update (omit to update all)"},
-			"project_id": map[string]any{"type": "string", "description": "Project slug to re-apply workflows to (optional, auto-detected if omitted)"},
+			"name":         map[string]any{"type": "string", "description": "Pack name to update (omit to update all)"},
+			"project_id":   map[string]any{"type": "string", "description": "Project slug to re-apply workflows to (optional, auto-detected if omitted)"},
+			"wait_seconds": map[string]any{"type": "number", "description": "Seconds to wait if another pack operation holds the workspace lock (default 30, 0 to fail immediately)"},
 		},
 	})
 	return s
 }
 
-func UpdatePack(ps *storage.PackStorage, workspace string) ToolHandler {
+func UpdatePack(ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock) ToolHandler {
 	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
 		name := helpers.GetString(req.Arguments, "name")
 		projectID := helpers.GetString(req.Arguments, "project_id")
 
-		reg, regVersion, err := ps.ReadRegistry(ctx)
+		operation := "update_pack"
+		if name != "" {
+			operation += " " + name
+		}
+		release, busy := acquireWorkspace(ctx, lock, operation, req.Arguments)
+		if busy != nil {
+			return busy, nil
+		}
+		defer release()
+
+		reg, _, err := ps.ReadRegistry(ctx)
 		if err != nil {
 			return helpers.ErrorResult("storage_error", err.Error()), nil
 		}
 
-		var toUpdate map[string]*storage.PackEntry
+		var names []string
 		if name != "" {
-			entry, ok := reg.Packs[name]
-			if !ok {
+			if _, ok := reg.Packs[name]; !ok {
 				return helpers.ErrorResult("not_found", fmt.Sprintf("pack %q not installed", name)), nil
 			}
-			toUpdate = map[string]*storage.PackEntry{name: entry}
+			names = []string{name}
 		} else {
-			toUpdate = reg.Packs
+			for packName := range reg.Packs {
+				names = append(names, packName)
+			}
+			sort.Strings(names)
 		}
 
 		if projectID == "" {
 			projectID = detectActiveProject(workspace)
 		}
 
-		var updated []string
-		for packName, entry := range toUpdate {
-			// Remove old files.
-			packs.RemovePack(workspace, entry.Skills, entry.Agents, entry.Hooks, entry.Workflows)
+		policies, err := packs.LoadPolicies(workspace)
+		if err != nil {
+			return helpers.ErrorResult("invalid_policy", err.Error()), nil
+		}
 
-			// Re-install.
-			manifest, err := packs.InstallPack(workspace, entry.Repo, "")
+		// Fetch every pack the policy allows in parallel before touching the
+		// workspace.
+		var reqs []packs.FetchRequest
+		fetchIndex := make(map[string]int, len(names))
+		blocked := make(map[string]error)
+		for _, packName := range names {
+			entry := reg.Packs[packName]
+			if err := policies.CheckSource(entry.Repo); err != nil {
+				blocked[packName] = err
+				continue
+			}
+			fetchIndex[packName] = len(reqs)
+			reqs = append(reqs, packs.FetchRequest{Repo: entry.Repo, Constraint: entry.Constraint})
+		}
+		fetches := packs.FetchAll(ctx, reqs, packs.DefaultFetchWorkers)
+
+		results := make(map[string]*packUpdateResult, len(names))
+		fetched := make(map[string]*packs.FetchedPack)
+		verified := make(map[string]verifiedPack)
+		requires := make(map[string][]string)
+		for _, packName := range names {
+			entry := reg.Packs[packName]
+			res := &packUpdateResult{name: packName, from: entry.Version}
+			results[packName] = res
+			if err := blocked[packName]; err != nil {
+				res.status, res.detail = "failed", err.Error()
+				continue
+			}
+			fetch := fetches[fetchIndex[packName]]
+			if err := fetch.Err; err != nil {
+				res.status, res.detail = "failed", err.Error()
+				continue
+			}
+			f := fetch.Pack
+			defer f.Cleanup()
+			res.to = f.Manifest.Version
+			if entry.Commit != "" && entry.Commit == f.Commit {
+				res.status = "unchanged"
+				continue
+			}
+			verification, digests, err := verifyFetched(f, reg)
 			if err != nil {
-				return helpers.ErrorResult("update_error", fmt.Sprintf("update %s: %v", packName, err)), nil
+				res.status, res.detail = "failed", err.Error()
+				continue
+			}
+			if _, err := enforcePolicies(policies, f, verification.Signer); err != nil {
+				res.status, res.detail = "failed", err.Error()
+				continue
+			}
+			verified[packName] = verifiedPack{verification.Signer, digests}
+			fetched[packName] = f
+			requires[packName] = f.Manifest.Requires
+		}
+
+		// Apply in dependency order, continuing past individual failures.
+		newEntries := make(map[string]*storage.PackEntry)
+		approvals := make(map[string]*storage.HookApproval)
+		var droppedHooks []string
+		for _, packName := range packs.DependencyOrder(requires) {
+			entry, f, res := reg.Packs[packName], fetched[packName], results[packName]
+			manifest := f.Manifest
+
+			if err := f.Apply(workspace); err != nil {
+				res.status, res.detail = "failed", err.Error()
+				continue
 			}
+			old := packs.PackContents{Skills: entry.Skills, Agents: entry.Agents, Hooks: entry.Hooks, Workflows: entry.Workflows}
+			packs.RemoveReplaced(workspace, old, manifest.Contents)
+			for _, hook := range entry.Hooks {
+				if !slices.Contains(manifest.Contents.Hooks, hook) {
+					droppedHooks = append(droppedHooks, hook)
+				}
+			}
+			hookApprovals, pendingHooks, wiringErrs := gateHooks(workspace, packName, manifest.Contents.Hooks, manifest.HookEvents, reg.HookApprovals)
+			maps.Copy(approvals, hookApprovals)
+			var details []string
+			if len(pendingHooks) > 0 {
+				details = append(details, "hooks pending review: "+strings.Join(pendingHooks, ", "))
+			}
+			if len(wiringErrs) > 0 {
+				details = append(details, "hook wiring failed: "+strings.Join(wiringErrs, "; "))
+			}
+			res.detail = strings.Join(details, "; ")
 
 			// Re-apply workflows to the active project.
 			if len(manifest.Contents.Workflows) > 0 && projectID != "" {
@@ -212,27 +400,294 @@ func UpdatePack(ps *storage.PackStorage, workspace string) ToolHandler {
 				}
 			}
 
-			reg.Packs[packName] = &storage.PackEntry{
+			res.notes, _ = f.ReleaseNotes(ctx, entry.Version, entry.Commit)
+
+			newEntries[packName] = &storage.PackEntry{
 				Version:     manifest.Version,
 				Repo:        entry.Repo,
+				Commit:      f.Commit,
+				Constraint:  entry.Constraint,
 				InstalledAt: helpers.NowISO(),
 				Stacks:      manifest.Stacks,
+				Requires:    manifest.Requires,
 				Skills:      manifest.Contents.Skills,
 				Agents:      manifest.Contents.Agents,
 				Hooks:       manifest.Contents.Hooks,
 				Workflows:   manifest.Contents.Workflows,
+				License:     manifest.License,
+				Digests:     verified[packName].digests,
+				Signer:      verified[packName].signer,
+				HookEvents:  registryHookEvents(manifest.HookEvents),
+			}
+			res.status = "updated"
+		}
+
+		// Record only the successful updates, in a single registry write.
+		if len(newEntries) > 0 {
+			reg, err := ps.UpdateRegistry(ctx, func(reg *storage.PackRegistry) error {
+				for packName, entry := range newEntries {
+					reg.Packs[packName] = entry
+				}
+				for _, hook := range droppedHooks {
+					delete(reg.HookApprovals, hook)
+				}
+				recordHookApprovals(reg, approvals)
+				return nil
+			})
+			if err != nil {
+				return registryError(err), nil
+			}
+			if err := recordProvenance(workspace, reg); err != nil {
+				return helpers.TextResult(formatUpdateResults(names, results) + fmt.Sprintf("\nProvenance warning: %v\n", err)), nil
+			}
+		}
+
+		return helpers.TextResult(formatUpdateResults(names, results)), nil
+	}
+}
+
+// packUpdateResult records what update_pack did to one pack.
+type packUpdateResult struct {
+	name   string
+	status string // updated, unchanged, or failed
+	from   string
+	to     string
+	detail string
+	notes  *packs.ReleaseNotes
+}
+
+// formatUpdateResults renders the per-pack update table and totals.
+func formatUpdateResults(names []string, results map[string]*packUpdateResult) string {
+	counts := make(map[string]int)
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Pack Updates (%d)\n\n", len(names))
+	fmt.Fprintf(&b, "| Pack | Status | Version | Detail |\n")
+	fmt.Fprintf(&b, "|------|--------|---------|--------|\n")
+	for _, name := range names {
+		res := results[name]
+		counts[res.status]++
+		version := res.from
+		if res.status == "updated" && res.to != res.from {
+			version = fmt.Sprintf("%s → %s", res.from, res.to)
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n",
+			filepath.Base(name), res.status, version, strings.ReplaceAll(res.detail, "|", "\\|"))
+	}
+	fmt.Fprintf(&b, "\nUpdated %d, unchanged %d, failed %d.", counts["updated"], counts["unchanged"], counts["failed"])
+
+	for _, name := range names {
+		res := results[name]
+		if res.status != "updated" || res.notes.Empty() {
+			continue
+		}
+		fmt.Fprintf(&b, "\n\n### %s %s → %s\n\n%s", filepath.Base(name), orDash(res.from), orDash(res.to), strings.TrimRight(res.notes.Markdown(), "\n"))
+	}
+	return b.String()
+}
+
+// --- outdated_packs ---
+
+// outdatedTimeout bounds how long outdated checks wait on remote sources.
+const outdatedTimeout = 30 * time.Second
+
+func OutdatedPacksSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name":  map[string]any{"type": "string", "description": "Pack name to check (omit to check all)"},
+			"notes": map[string]any{"type": "boolean", "description": "Fetch release notes for outdated packs (default true)"},
+		},
+	})
+	return s
+}
+
+func OutdatedPacks(ps *storage.PackStorage) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		name := helpers.GetString(req.Arguments, "name")
+
+		reg, _, err := ps.ReadRegistry(ctx)
+		if err != nil {
+			return helpers.ErrorResult("storage_error", err.Error()), nil
+		}
+		if name != "" {
+			if _, ok := reg.Packs[name]; !ok {
+				return helpers.ErrorResult("not_found", fmt.Sprintf("pack %q not installed", name)), nil
 			}
-			updated = append(updated, packName)
 		}
+		if len(reg.Packs) == 0 {
+			return helpers.TextResult("No packs installed."), nil
+		}
+
+		reports := checkOutdated(ctx, reg, name)
+		var notes map[string]*packs.ReleaseNotes
+		if v, ok := req.Arguments.GetFields()["notes"]; !ok || v.GetBoolValue() {
+			notes = collectReleaseNotes(ctx, reports)
+		}
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "## Outdated Packs (%d)\n\n", len(reports))
+		fmt.Fprintf(&b, "| Pack | Current | Wanted | Latest | Status |\n")
+		fmt.Fprintf(&b, "|------|---------|--------|--------|--------|\n")
+		outdated := 0
+		for _, r := range reports {
+			status := r.Status
+			if r.Err != nil {
+				status = fmt.Sprintf("%s: %s", r.Status, strings.ReplaceAll(r.Err.Error(), "|", "\\|"))
+			}
+			if r.Status == packs.StatusOutdated {
+				outdated++
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n",
+				filepath.Base(r.Name), installedVersion(r.OutdatedQuery), orDash(r.Wanted), orDash(r.Latest), status)
+		}
+		if outdated > 0 {
+			fmt.Fprintf(&b, "\n%d pack(s) can be updated with `update_pack`.", outdated)
+		} else {
+			fmt.Fprintf(&b, "\nAll packs are up to date within their constraints.")
+		}
+		for _, r := range reports {
+			if n := notes[r.Name]; !n.Empty() {
+				fmt.Fprintf(&b, "\n\n### %s %s → %s\n\n%s", filepath.Base(r.Name), orDash(r.Version), r.Wanted, strings.TrimRight(n.Markdown(), "\n"))
+			}
+		}
+		return helpers.TextResult(b.String()), nil
+	}
+}
+
+// checkOutdated queries the sources of the named pack, or every installed
+// pack when name is empty, sorted by name.
+func checkOutdated(ctx context.Context, reg *storage.PackRegistry, name string) []packs.OutdatedReport {
+	var names []string
+	for packName := range reg.Packs {
+		if name == "" || packName == name {
+			names = append(names, packName)
+		}
+	}
+	sort.Strings(names)
+
+	queries := make([]packs.OutdatedQuery, len(names))
+	for i, packName := range names {
+		entry := reg.Packs[packName]
+		queries[i] = packs.OutdatedQuery{
+			Name:       packName,
+			Repo:       entry.Repo,
+			Version:    entry.Version,
+			Commit:     entry.Commit,
+			Constraint: entry.Constraint,
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, outdatedTimeout)
+	defer cancel()
+	return packs.CheckOutdated(ctx, queries, packs.DefaultFetchWorkers)
+}
+
+// collectReleaseNotes fetches release notes for the outdated packs in
+// reports, bounded by the same timeout as the outdated check.
+func collectReleaseNotes(ctx context.Context, reports []packs.OutdatedReport) map[string]*packs.ReleaseNotes {
+	ctx, cancel := context.WithTimeout(ctx, outdatedTimeout)
+	defer cancel()
+	return packs.CollectReleaseNotes(ctx, reports, packs.DefaultFetchWorkers)
+}
+
+// installedVersion renders the installed manifest version with its commit.
+func installedVersion(q packs.OutdatedQuery) string {
+	if q.Commit == "" {
+		return orDash(q.Version)
+	}
+	return fmt.Sprintf("%s (%s)", orDash(q.Version), packs.ShortCommit(q.Commit))
+}
 
-		if _, err := ps.WriteRegistry(ctx, reg, regVersion); err != nil {
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// --- verify_packs ---
+
+func VerifyPacksSchema() *structpb.Struct {
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string", "description": "Pack name to verify (omit to verify all)"},
+		},
+	})
+	return s
+}
+
+func VerifyPacks(ps *storage.PackStorage, workspace string) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		name := helpers.GetString(req.Arguments, "name")
+
+		reg, _, err := ps.ReadRegistry(ctx)
+		if err != nil {
 			return helpers.ErrorResult("storage_error", err.Error()), nil
 		}
+		var names []string
+		if name != "" {
+			if _, ok := reg.Packs[name]; !ok {
+				return helpers.ErrorResult("not_found", fmt.Sprintf("pack %q not installed", name)), nil
+			}
+			names = []string{name}
+		} else {
+			for packName := range reg.Packs {
+				names = append(names, packName)
+			}
+			sort.Strings(names)
+		}
+		if len(names) == 0 {
+			return helpers.TextResult("## Pack Verification\n\nNo packs installed."), nil
+		}
 
-		return helpers.TextResult(fmt.Sprintf("Updated %d pack(s): %s", len(updated), strings.Join(updated, ", "))), nil
+		var b strings.Builder
+		var problems []string
+		failed := 0
+		fmt.Fprintf(&b, "## Pack Verification\n\n")
+		fmt.Fprintf(&b, "| Pack | Version | Signer | Files | Status |\n")
+		fmt.Fprintf(&b, "|------|---------|--------|-------|--------|\n")
+		for _, packName := range names {
+			entry := reg.Packs[packName]
+			status := "ok"
+			if len(entry.Digests) == 0 {
+				status = "no digests recorded"
+			}
+			changed := 0
+			for _, f := range packs.VerifyInstalled(workspace, entry.Digests) {
+				if f.Status != packs.FileOK && !rejectedHook(reg, f.Path) {
+					changed++
+					problems = append(problems, fmt.Sprintf("- %s: `%s` %s", packName, f.Path, f.Status))
+				}
+			}
+			if changed > 0 {
+				status = fmt.Sprintf("%d file(s) changed", changed)
+				failed++
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %d | %s |\n", packName, entry.Version, orDash(entry.Signer), len(entry.Digests), status)
+		}
+		if len(problems) > 0 {
+			fmt.Fprintf(&b, "\n### Changed files\n\n%s\n", strings.Join(problems, "\n"))
+		}
+		fmt.Fprintf(&b, "\n%d of %d pack(s) verified.", len(names)-failed, len(names))
+		if failed > 0 {
+			fmt.Fprintf(&b, " Run `update_pack` or reinstall to restore the published files.")
+		}
+		return helpers.TextResult(b.String()), nil
 	}
 }
 
+// rejectedHook reports whether an installed path is a hook the user
+// rejected, whose published content is deliberately not installed.
+func rejectedHook(reg *storage.PackRegistry, rel string) bool {
+	name, ok := strings.CutPrefix(rel, "hooks/")
+	if !ok {
+		return false
+	}
+	a := reg.HookApprovals[strings.TrimSuffix(name, ".sh")]
+	return a != nil && a.Status == storage.HookRejected
+}
+
 // --- list_packs ---
 
 func ListPacksSchema() *structpb.Struct {
@@ -332,6 +787,7 @@ func GetPack(ps *storage.PackStorage) ToolHandler {
 		fmt.Fprintf(&b, "## Pack: %s\n\n", name)
 		fmt.Fprintf(&b, "- **Version:** %s\n", entry.Version)
 		fmt.Fprintf(&b, "- **Repo:** %s\n", entry.Repo)
+		fmt.Fprintf(&b, "- **License:** %s\n", orDash(entry.License))
 		fmt.Fprintf(&b, "- **Installed:** %s\n", entry.InstalledAt)
 		if len(entry.Stacks) > 0 {
 			fmt.Fprintf(&b, "- **Stacks:** %s\n", strings.Join(entry.Stacks, ", "))
@@ -410,6 +866,91 @@ func SearchPacks(ps *storage.PackStorage) ToolHandler {
 	}
 }
 
+// --- registry helpers ---
+
+// errPackNotInstalled is returned from UpdateRegistry mutations when the
+// named pack is missing, so handlers can report not_found.
+var errPackNotInstalled = errors.New("pack not installed")
+
+// registryError converts an UpdateRegistry failure into a tool error result,
+// reporting lost optimistic-concurrency races separately from other failures.
+func registryError(err error) *pluginv1.ToolResponse {
+	if errors.Is(err, storage.ErrVersionConflict) {
+		return helpers.ErrorResult("conflict", fmt.Sprintf("registry was modified concurrently, please retry: %v", err))
+	}
+	return helpers.ErrorResult("storage_error", err.Error())
+}
+
+// verifyFetched checks a fetched pack against the trusted keys configured
+// for its repo and returns the verification and the digests to record.
+func verifyFetched(f *packs.FetchedPack, reg *storage.PackRegistry) (*packs.Verification, map[string]string, error) {
+	verification, err := f.Verify(reg.KeysFor(f.Repo))
+	if err != nil {
+		return nil, nil, err
+	}
+	digests, err := f.InstalledDigests()
+	if err != nil {
+		return nil, nil, err
+	}
+	return verification, digests, nil
+}
+
+// verifiedPack is what update_pack records about a verified fetch.
+type verifiedPack struct {
+	signer  string
+	digests map[string]string
+}
+
+// --- policy helpers ---
+
+// enforcePolicies checks a fetched, verified pack against the install
+// policies and returns the risk scans of its hooks.
+func enforcePolicies(policies packs.Policies, f *packs.FetchedPack, signer string) (map[string]*packs.HookScan, error) {
+	if err := policies.CheckManifest(f.Manifest, signer); err != nil {
+		return nil, err
+	}
+	scans, err := f.ScanHooks()
+	if err != nil {
+		return nil, err
+	}
+	return scans, policies.CheckHooks(scans)
+}
+
+// policyError maps a failed policy check to a tool error result.
+func policyError(err error) *pluginv1.ToolResponse {
+	var violation *packs.PolicyViolation
+	if errors.As(err, &violation) {
+		return helpers.ErrorResult("policy_violation", err.Error()+". Nothing was installed.")
+	}
+	return helpers.ErrorResult("install_error", err.Error())
+}
+
+// --- workspace lock helpers ---
+
+// defaultLockWait is how long mutating tools wait for the workspace lock when
+// the caller does not pass wait_seconds.
+const defaultLockWait = 30 * time.Second
+
+// acquireWorkspace takes the workspace lock for a mutating pack operation. On
+// failure it returns a tool error result instead of a release function.
+func acquireWorkspace(ctx context.Context, lock *packs.WorkspaceLock, operation string, args *structpb.Struct) (func(), *pluginv1.ToolResponse) {
+	wait := defaultLockWait
+	if args != nil {
+		if v, ok := args.Fields["wait_seconds"]; ok {
+			wait = time.Duration(v.GetNumberValue() * float64(time.Second))
+		}
+	}
+	release, err := lock.Acquire(ctx, operation, wait)
+	if err != nil {
+		var busy *packs.BusyError
+		if errors.As(err, &busy) {
+			return nil, helpers.ErrorResult("busy", err.Error())
+		}
+		return nil, helpers.ErrorResult("lock_error", err.Error())
+	}
+	return release, nil
+}
+
 // --- workflow bridge helpers ---
 
 // applyWorkflowToProject loads a YAML workflow file and upserts it into the
@@ -481,11 +1022,21 @@ func applyWorkflowToProject(projectID, yamlPath string) error {
 // detectActiveProject scans .projects/ for the first project with a
 // project.json config file and returns its slug or id.
 func detectActiveProject(workspace string) string {
+	if projects := workspaceProjects(workspace); len(projects) > 0 {
+		return projects[0]
+	}
+	return ""
+}
+
+// workspaceProjects returns the slug or id of every project in .projects/
+// with a project.json config file, in directory order.
+func workspaceProjects(workspace string) []string {
 	projectsDir := filepath.Join(workspace, ".projects")
 	entries, err := os.ReadDir(projectsDir)
 	if err != nil {
-		return ""
+		return nil
 	}
+	var projects []string
 	for _, e := range entries {
 		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
 			continue
@@ -499,16 +1050,24 @@ func detectActiveProject(workspace string) string {
 			Slug string `json:"slug"`
 			ID   string `json:"id"`
 		}
+		// Fallback: use directory name as slug.
+		id := e.Name()
 		if json.Unmarshal(data, &cfg) == nil {
-			if cfg.Slug != "" {
-				return cfg.Slug
-			}
-			if cfg.ID != "" {
-				return cfg.ID
-			}
+			id = cmp.Or(cfg.Slug, cfg.ID, id)
 		}
-		// Fallback: use directory name as slug.
-		return e.Name()
+		projects = append(projects, id)
 	}
-	return ""
+	return projects
+}
+
+// workflowProjects returns the workspace projects whose workflow in
+// globaldb is the named workflow, as applyWorkflowToProject records it.
+func workflowProjects(workspace, name string) []string {
+	var projects []string
+	for _, projectID := range workspaceProjects(workspace) {
+		if rec, err := globaldb.GetProjectWorkflow(projectID); err == nil && rec != nil && rec.Name == name {
+			projects = append(projects, projectID)
+		}
+	}
+	return projects
 }
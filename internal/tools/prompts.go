@@ -185,16 +185,32 @@ func AuditPacks(ps *storage.PackStorage, workspace string) PromptHandler {
 			totalSkills := 0
 			totalAgents := 0
 			totalHooks := 0
+			outdated := 0
 
-			for name, info := range reg.Packs {
+			reports := checkOutdated(ctx, reg, "")
+			notes := collectReleaseNotes(ctx, reports)
+			for _, r := range reports {
+				name, info := r.Name, reg.Packs[r.Name]
 				fmt.Fprintf(&b, "### %s (v%s)\n", name, info.Version)
 				fmt.Fprintf(&b, "- Repo: %s\n", info.Repo)
 				fmt.Fprintf(&b, "- Installed: %s\n", info.InstalledAt)
+				if r.Err != nil {
+					fmt.Fprintf(&b, "- Updates: could not check (%v)\n", r.Err)
+				} else {
+					fmt.Fprintf(&b, "- Updates: %s (current %s, wanted %s, latest %s)\n",
+						r.Status, installedVersion(r.OutdatedQuery), orDash(r.Wanted), orDash(r.Latest))
+				}
+				if r.Status == packs.StatusOutdated {
+					outdated++
+				}
 				fmt.Fprintf(&b, "- Skills: %s\n", strings.Join(info.Skills, ", "))
 				fmt.Fprintf(&b, "- Agents: %s\n", strings.Join(info.Agents, ", "))
 				if len(info.Hooks) > 0 {
 					fmt.Fprintf(&b, "- Hooks: %s\n", strings.Join(info.Hooks, ", "))
 				}
+				if n := notes[r.Name]; !n.Empty() {
+					fmt.Fprintf(&b, "\nChanges up to %s:\n\n%s", r.Wanted, n.Markdown())
+				}
 				fmt.Fprintf(&b, "\n")
 
 				totalSkills += len(info.Skills)
@@ -204,11 +220,14 @@ func AuditPacks(ps *storage.PackStorage, workspace string) PromptHandler {
 
 			fmt.Fprintf(&b, "**Totals:** %d skills, %d agents, %d hooks across %d packs\n\n",
 				totalSkills, totalAgents, totalHooks, len(reg.Packs))
-			fmt.Fprintf(&b, "Use `update_pack` to check for updates, or `remove_pack` to uninstall.")
+			if outdated > 0 {
+				fmt.Fprintf(&b, "%d pack(s) have updates available. ", outdated)
+			}
+			fmt.Fprintf(&b, "Use `update_pack` to apply updates, or `remove_pack` to uninstall.")
 		}
 
 		return &pluginv1.PromptGetResponse{
-			Description: "Audit installed packs: versions, contents, and totals",
+			Description: "Audit installed packs: versions, available updates, contents, and totals",
 			Messages: []*pluginv1.PromptMessage{
 				{
 					Role:    "user",
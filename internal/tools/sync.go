@@ -0,0 +1,426 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	pluginv1 "github.com/orchestra-mcp/gen-go/orchestra/plugin/v1"
+	"github.com/orchestra-mcp/sdk-go/helpers"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/packs"
+	"github.com/orchestra-mcp/plugin-tools-marketplace/internal/storage"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// SyncWorkspaceContent brings the skills, agents, hooks, and workflows under
+// .claude/ and their storage entries in line, or with dryRun only plans it.
+// Each action that fails to apply carries its error; the returned error is
+// for failures that stop the whole sync. Callers hold the workspace lock
+// unless dryRun is set.
+func SyncWorkspaceContent(ctx context.Context, ps *storage.PackStorage, workspace string, opts packs.SyncOptions, dryRun bool) ([]packs.SyncAction, error) {
+	kinds := opts.Types
+	if len(kinds) == 0 {
+		kinds = packs.SyncKinds
+	}
+	reg, _, err := ps.ReadRegistry(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]*packs.SyncItem)
+	item := func(kind, slug string) *packs.SyncItem {
+		key := packs.SyncKey(kind, slug)
+		if items[key] == nil {
+			items[key] = &packs.SyncItem{Kind: kind, Slug: slug}
+		}
+		return items[key]
+	}
+	for key, f := range packs.ScanSyncFiles(workspace, kinds) {
+		kind, slug, _ := strings.Cut(key, "/")
+		item(kind, slug).File = f
+	}
+	for _, kind := range kinds {
+		stored, err := readStoredContent(ctx, ps, kind)
+		if err != nil {
+			return nil, err
+		}
+		for slug, s := range stored {
+			item(kind, slug).Stored = s
+		}
+	}
+	for key, r := range reg.ContentSync {
+		kind, slug, _ := strings.Cut(key, "/")
+		if slices.Contains(kinds, kind) {
+			item(kind, slug).Record = (*packs.SyncRecord)(r)
+		}
+	}
+
+	prov := contentProvenance(workspace, reg)
+	wiring, _ := packs.ReadHookWiring(workspace)
+	list := make([]packs.SyncItem, 0, len(items))
+	for _, it := range items {
+		if it.File != nil {
+			it.Tags = syncTags(reg, prov, wiring, it.Kind, it.Slug)
+		}
+		list = append(list, *it)
+	}
+	actions := packs.PlanSync(list, opts)
+	if dryRun {
+		return actions, nil
+	}
+
+	records := make(map[string]*storage.SyncRecord)
+	var staged []string
+	for i := range actions {
+		a := &actions[i]
+		key := packs.SyncKey(a.Kind, a.Slug)
+		record, err := applySyncAction(ctx, ps, workspace, items[key], a.Op)
+		if err != nil {
+			a.Err = err
+			continue
+		}
+		switch a.Op {
+		case packs.SyncImport, packs.SyncExport, packs.SyncLink, packs.SyncRetag, packs.SyncDeleteStored, packs.SyncDeleteFile, packs.SyncForget:
+			records[key] = record // nil removes the record
+		}
+		if a.Op == packs.SyncExport && a.Kind == packs.ContentHooks {
+			staged = append(staged, a.Slug)
+		}
+	}
+	approvals := gateSyncedHooks(workspace, reg, prov, staged)
+	for name, ap := range approvals {
+		if ap.Status == storage.HookApproved {
+			records[packs.SyncKey(packs.ContentHooks, name)].FileHash = ap.Digest
+		}
+	}
+	if len(records) == 0 {
+		return actions, nil
+	}
+	_, err = ps.UpdateRegistry(ctx, func(reg *storage.PackRegistry) error {
+		recordHookApprovals(reg, approvals)
+		if reg.ContentSync == nil {
+			reg.ContentSync = make(map[string]*storage.SyncRecord)
+		}
+		for key, r := range records {
+			if r == nil {
+				delete(reg.ContentSync, key)
+			} else {
+				reg.ContentSync[key] = r
+			}
+		}
+		return nil
+	})
+	return actions, err
+}
+
+// readStoredContent reads the storage entries of one kind, keyed by slug.
+// Entries that cannot be read are left out.
+func readStoredContent(ctx context.Context, ps *storage.PackStorage, kind string) (map[string]*packs.SyncStored, error) {
+	entries, err := ps.StorageList(ctx, "."+kind, "*.md")
+	if err != nil {
+		return nil, fmt.Errorf("list .%s: %w", kind, err)
+	}
+	stored := make(map[string]*packs.SyncStored, len(entries))
+	for _, e := range entries {
+		resp, err := ps.StorageRead(ctx, e.Path)
+		if err != nil {
+			continue
+		}
+		s := &packs.SyncStored{Body: resp.Content, Metadata: resp.Metadata.AsMap(), Version: resp.Version}
+		if e.ModifiedAt != nil {
+			s.ModTime = e.ModifiedAt.AsTime()
+		}
+		stored[strings.TrimSuffix(path.Base(e.Path), ".md")] = s
+	}
+	return stored, nil
+}
+
+// syncTags returns the storage-only fields an installed item's entry should
+// carry: its provenance, when it came from a pack, and for a hook the event
+// it runs on, from settings.json or else from its pack.
+func syncTags(reg *storage.PackRegistry, prov map[string]*packs.Provenance, wiring map[string][]packs.HookWiring, kind, slug string) map[string]any {
+	p := prov[packs.SyncKey(kind, slug)]
+	tags := p.Tags()
+	if kind != packs.ContentHooks {
+		return tags
+	}
+	var ev packs.HookEvent
+	if w := wiring[slug]; len(w) > 0 {
+		ev = packs.HookEvent{Event: w[0].Event, Matcher: w[0].Matcher, Timeout: w[0].Timeout}
+	} else if p != nil && reg.Packs[p.Pack] != nil {
+		ev = packs.HookEvent(reg.Packs[p.Pack].HookEvents[slug])
+	}
+	if ev.Event != "" {
+		tags["event_type"] = ev.Event
+		tags["matcher"] = nil
+		tags["timeout"] = nil
+		if ev.Matcher != "" {
+			tags["matcher"] = ev.Matcher
+		}
+		if ev.Timeout > 0 {
+			tags["timeout"] = ev.Timeout
+		}
+	}
+	return tags
+}
+
+// contentProvenance returns where each installed item came from, keyed by
+// packs.SyncKey with workflow file extensions dropped. Items in
+// .claude/.provenance.json that the registry does not know, as in a
+// workspace checked out with its .claude/ directory, are kept as recorded.
+func contentProvenance(workspace string, reg *storage.PackRegistry) map[string]*packs.Provenance {
+	prov, err := packs.ReadProvenance(workspace)
+	if err != nil {
+		prov = make(map[string]*packs.Provenance)
+	}
+	maps.Copy(prov, registryProvenance(workspace, reg))
+	return prov
+}
+
+// registryProvenance returns the provenance of the content of every pack in
+// the registry, checking each item's files against the recorded digests.
+func registryProvenance(workspace string, reg *storage.PackRegistry) map[string]*packs.Provenance {
+	prov := make(map[string]*packs.Provenance)
+	for _, name := range slices.Sorted(maps.Keys(reg.Packs)) {
+		entry := reg.Packs[name]
+		for kind, list := range map[string][]string{
+			packs.ContentSkills:    entry.Skills,
+			packs.ContentAgents:    entry.Agents,
+			packs.ContentHooks:     entry.Hooks,
+			packs.ContentWorkflows: entry.Workflows,
+		} {
+			for _, item := range list {
+				slug := item
+				if kind == packs.ContentWorkflows {
+					slug = strings.TrimSuffix(item, path.Ext(item))
+				}
+				prov[packs.SyncKey(kind, slug)] = &packs.Provenance{
+					Pack:     name,
+					Version:  entry.Version,
+					Commit:   entry.Commit,
+					Repo:     entry.Repo,
+					Path:     packs.SourcePath(kind, item),
+					Modified: packs.ContentModified(workspace, kind, item, entry.Digests),
+				}
+			}
+		}
+	}
+	return prov
+}
+
+// recordProvenance writes .claude/.provenance.json from the registry after
+// a pack is installed, updated, or removed.
+func recordProvenance(workspace string, reg *storage.PackRegistry) error {
+	return packs.WriteProvenance(workspace, registryProvenance(workspace, reg))
+}
+
+// applySyncAction carries out one planned operation and returns the record
+// of the item afterwards, or nil once it no longer exists on either side.
+func applySyncAction(ctx context.Context, ps *storage.PackStorage, workspace string, it *packs.SyncItem, op string) (*storage.SyncRecord, error) {
+	storagePath := packs.SyncStoragePath(it.Kind, it.Slug)
+	record := func(file []byte, body []byte, meta map[string]any) *storage.SyncRecord {
+		var fileHash string
+		if file != nil {
+			fileHash = packs.ContentHash(file)
+		}
+		return &storage.SyncRecord{
+			FileHash:   fileHash,
+			StoredHash: packs.StoredHash(it.Kind, body, meta),
+			SyncedAt:   helpers.NowISO(),
+		}
+	}
+
+	switch op {
+	case packs.SyncLink:
+		return record(it.File.Content, it.Stored.Body, it.Stored.Metadata), nil
+
+	case packs.SyncRetag:
+		fields := maps.Clone(it.Stored.Metadata)
+		packs.ApplyTags(fields, it.Tags)
+		meta, err := structpb.NewStruct(fields)
+		if err != nil {
+			return nil, fmt.Errorf("build metadata: %w", err)
+		}
+		if _, err := ps.StorageWrite(ctx, storagePath, meta, it.Stored.Body, it.Stored.Version); err != nil {
+			return nil, err
+		}
+		return record(it.File.Content, it.Stored.Body, meta.AsMap()), nil
+
+	case packs.SyncImport:
+		var previous map[string]any
+		var version int64
+		if it.Stored != nil {
+			previous, version = it.Stored.Metadata, it.Stored.Version
+		}
+		body, fields := packs.StoredFromFile(it.Kind, it.Slug, it.File.Content, previous)
+		packs.ApplyTags(fields, it.Tags)
+		if it.Stored == nil {
+			fields["scope"] = "personal"
+			fields["created_at"] = helpers.NowISO()
+		} else {
+			fields["updated_at"] = helpers.NowISO()
+		}
+		meta, err := structpb.NewStruct(fields)
+		if err != nil {
+			return nil, fmt.Errorf("build metadata: %w", err)
+		}
+		if _, err := ps.StorageWrite(ctx, storagePath, meta, body, version); err != nil {
+			return nil, err
+		}
+		return record(it.File.Content, body, meta.AsMap()), nil
+
+	case packs.SyncExport:
+		var previous []byte
+		if it.File != nil {
+			previous = it.File.Content
+		}
+		content, err := packs.FileFromStored(it.Kind, it.Slug, it.Stored.Body, it.Stored.Metadata, previous)
+		if err != nil {
+			return nil, err
+		}
+		if err := packs.WriteSyncFile(workspace, it.Kind, it.Slug, content); err != nil {
+			return nil, err
+		}
+		if it.Kind == packs.ContentHooks {
+			// Staged for review; the installed script, if any, is unchanged.
+			return record(previous, it.Stored.Body, it.Stored.Metadata), nil
+		}
+		return record(content, it.Stored.Body, it.Stored.Metadata), nil
+
+	case packs.SyncDeleteStored:
+		if err := ps.StorageDelete(ctx, storagePath); err != nil {
+			return nil, err
+		}
+		return nil, nil
+
+	case packs.SyncDeleteFile:
+		return nil, packs.DeleteSyncFile(workspace, it.Kind, it.Slug)
+	}
+	return nil, nil
+}
+
+// --- sync_content ---
+
+func SyncContentSchema() *structpb.Struct {
+	kinds := make([]any, len(packs.SyncKinds))
+	for i, k := range packs.SyncKinds {
+		kinds[i] = k
+	}
+	s, _ := structpb.NewStruct(map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"direction":    map[string]any{"type": "string", "enum": []any{packs.SyncBoth, packs.SyncToStorage, packs.SyncToFiles}, "description": "Carry changes both ways, only from .claude to storage, or only from storage to .claude (default: both)"},
+			"on_conflict":  map[string]any{"type": "string", "enum": []any{packs.ConflictSkip, packs.ConflictFile, packs.ConflictStorage, packs.ConflictNewer}, "description": "When both sides changed: report it, let the file or storage win, or keep the newer side (default: skip)"},
+			"types":        map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": kinds}, "description": "Content types to sync (default: all)"},
+			"names":        map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": "Slugs or globs to sync (default: all)"},
+			"dry_run":      map[string]any{"type": "boolean", "description": "Report what would change without changing anything"},
+			"wait_seconds": map[string]any{"type": "number", "description": "Seconds to wait if another pack operation holds the workspace lock (default 30, 0 to fail immediately)"},
+		},
+	})
+	return s
+}
+
+func SyncContent(ps *storage.PackStorage, workspace string, lock *packs.WorkspaceLock) ToolHandler {
+	return func(ctx context.Context, req *pluginv1.ToolRequest) (*pluginv1.ToolResponse, error) {
+		opts := packs.SyncOptions{
+			Direction:  helpers.GetString(req.Arguments, "direction"),
+			OnConflict: helpers.GetString(req.Arguments, "on_conflict"),
+			Types:      helpers.GetStringSlice(req.Arguments, "types"),
+			Names:      helpers.GetStringSlice(req.Arguments, "names"),
+		}
+		if opts.Direction == "" {
+			opts.Direction = packs.SyncBoth
+		}
+		if opts.OnConflict == "" {
+			opts.OnConflict = packs.ConflictSkip
+		}
+		if !slices.Contains([]string{packs.SyncBoth, packs.SyncToStorage, packs.SyncToFiles}, opts.Direction) {
+			return helpers.ErrorResult("validation_error", fmt.Sprintf("unknown direction %q (want both, to_storage, or to_files)", opts.Direction)), nil
+		}
+		if !slices.Contains([]string{packs.ConflictSkip, packs.ConflictFile, packs.ConflictStorage, packs.ConflictNewer}, opts.OnConflict) {
+			return helpers.ErrorResult("validation_error", fmt.Sprintf("unknown on_conflict %q (want skip, file, storage, or newer)", opts.OnConflict)), nil
+		}
+		for _, kind := range opts.Types {
+			if !slices.Contains(packs.SyncKinds, kind) {
+				return helpers.ErrorResult("validation_error", fmt.Sprintf("unknown type %q (want %s)", kind, strings.Join(packs.SyncKinds, ", "))), nil
+			}
+		}
+		dryRun := helpers.GetBool(req.Arguments, "dry_run")
+
+		if !dryRun {
+			release, busy := acquireWorkspace(ctx, lock, "sync_content", req.Arguments)
+			if busy != nil {
+				return busy, nil
+			}
+			defer release()
+		}
+		actions, err := SyncWorkspaceContent(ctx, ps, workspace, opts, dryRun)
+		if err != nil {
+			if errors.Is(err, storage.ErrVersionConflict) {
+				return registryError(err), nil
+			}
+			return helpers.ErrorResult("sync_error", err.Error()), nil
+		}
+		return helpers.TextResult(formatSyncActions(actions, opts, dryRun)), nil
+	}
+}
+
+// formatSyncActions renders a sync as a table of everything that changed or
+// needs attention, followed by counts per operation.
+func formatSyncActions(actions []packs.SyncAction, opts packs.SyncOptions, dryRun bool) string {
+	var b strings.Builder
+	if dryRun {
+		fmt.Fprintf(&b, "## Content Sync (dry run)\n\n")
+	} else {
+		fmt.Fprintf(&b, "## Content Sync\n\n")
+	}
+	fmt.Fprintf(&b, "- **Direction:** %s\n", opts.Direction)
+	fmt.Fprintf(&b, "- **On conflict:** %s\n\n", opts.OnConflict)
+
+	counts := make(map[string]int)
+	failed := 0
+	var rows []packs.SyncAction
+	for _, a := range actions {
+		if a.Err != nil {
+			failed++
+		} else {
+			counts[a.Op]++
+		}
+		if a.Op != packs.SyncUnchanged || a.Err != nil {
+			rows = append(rows, a)
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Fprintf(&b, "Everything is in sync (%d item(s)).\n", len(actions))
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "| Type | Name | Action | Reason |\n")
+	fmt.Fprintf(&b, "|------|------|--------|--------|\n")
+	for _, a := range rows {
+		op, reason := a.Op, a.Reason
+		if a.Err != nil {
+			op, reason = a.Op+" (failed)", a.Err.Error()
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", strings.TrimSuffix(a.Kind, "s"), a.Slug, op, reason)
+	}
+
+	var summary []string
+	for _, op := range []string{packs.SyncImport, packs.SyncExport, packs.SyncDeleteStored, packs.SyncDeleteFile, packs.SyncLink, packs.SyncRetag, packs.SyncForget, packs.SyncUnchanged, packs.SyncSkip, packs.SyncConflict} {
+		if counts[op] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[op], op))
+		}
+	}
+	if failed > 0 {
+		summary = append(summary, fmt.Sprintf("%d failed", failed))
+	}
+	fmt.Fprintf(&b, "\n**Summary:** %s\n", strings.Join(summary, ", "))
+	if counts[packs.SyncConflict] > 0 {
+		fmt.Fprintf(&b, "\nResolve conflicts by editing one side, or rerun with `on_conflict` set to `file`, `storage`, or `newer`.\n")
+	}
+	return b.String()
+}